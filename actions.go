@@ -0,0 +1,255 @@
+package sarufi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Types of the actions found in the replies of knowledge base
+// bots, as keys of the "actions" array items.
+const (
+	ActionSendMessage     = "send_message"
+	ActionSendReplyButton = "send_reply_button"
+	ActionSendButton      = "send_button"
+	ActionSendImages      = "send_images"
+	ActionSendVideos      = "send_videos"
+	ActionSendAudios      = "send_audios"
+	ActionSendDocuments   = "send_documents"
+	ActionSendStickers    = "send_stickers"
+)
+
+// Action is one item of a bot reply. It is one of
+// *SendMessageAction, *ReplyButtonAction, *ListAction,
+// *MediaAction or *UnknownAction; use a type switch to handle it.
+type Action interface {
+	// ActionType returns the key of the action, e.g. "send_message".
+	ActionType() string
+}
+
+// SendMessageAction sends plain text messages.
+type SendMessageAction struct {
+	Message []string
+}
+
+func (a *SendMessageAction) ActionType() string { return ActionSendMessage }
+
+// ReplyButton is a button of a ReplyButtonAction.
+type ReplyButton struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// ReplyButtonAction sends a message with up to three reply buttons.
+type ReplyButtonAction struct {
+	Body    string
+	Buttons []ReplyButton
+}
+
+func (a *ReplyButtonAction) ActionType() string { return ActionSendReplyButton }
+
+// ListRow is an item of a ListSection.
+type ListRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// ListSection is a group of rows in a ListAction.
+type ListSection struct {
+	Title string    `json:"title,omitempty"`
+	Rows  []ListRow `json:"rows"`
+}
+
+// ListAction sends a list message: a button opening a menu of
+// sections and rows to pick from.
+type ListAction struct {
+	Header   string
+	Body     string
+	Footer   string
+	Button   string
+	Sections []ListSection
+}
+
+func (a *ListAction) ActionType() string { return ActionSendButton }
+
+// MediaFile is a file sent by a MediaAction.
+type MediaFile struct {
+	Link    string `json:"link"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// MediaAction sends images, videos, audios, documents or
+// stickers, depending on Type.
+type MediaAction struct {
+	Type  string
+	Files []MediaFile
+}
+
+func (a *MediaAction) ActionType() string { return a.Type }
+
+// UnknownAction keeps actions this package does not know about,
+// and known actions whose payload does not have the expected
+// shape, e.g. a send_message mixing text and objects. Items of
+// the array that are not JSON objects at all are kept with an
+// empty Type and the whole item as Raw.
+type UnknownAction struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+func (a *UnknownAction) ActionType() string { return a.Type }
+
+// ActionList is the "actions" array of a bot reply.
+type ActionList []Action
+
+// wire formats of the interactive actions
+type replyButtonJSON struct {
+	Type string `json:"type"`
+	Body struct {
+		Text string `json:"text"`
+	} `json:"body"`
+	Action struct {
+		Buttons []struct {
+			Type  string      `json:"type"`
+			Reply ReplyButton `json:"reply"`
+		} `json:"buttons"`
+	} `json:"action"`
+}
+
+type listJSON struct {
+	Header string `json:"header,omitempty"`
+	Body   string `json:"body"`
+	Footer string `json:"footer,omitempty"`
+	Action struct {
+		Button   string        `json:"button"`
+		Sections []ListSection `json:"sections"`
+	} `json:"action"`
+}
+
+// UnmarshalJSON decodes every item of the array into its typed
+// action. Items holding several keys give one action per key,
+// in key order. Payloads that cannot be decoded into their typed
+// action, and items that are not objects, are kept as an
+// *UnknownAction rather than failing the whole reply.
+func (l *ActionList) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+
+	actions := make(ActionList, 0, len(items))
+	for _, raw := range items {
+		var item map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &item); err != nil || item == nil {
+			actions = append(actions, &UnknownAction{Raw: append(json.RawMessage(nil), raw...)})
+			continue
+		}
+		keys := make([]string, 0, len(item))
+		for key := range item {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			actions = append(actions, decodeAction(key, item[key]))
+		}
+	}
+	*l = actions
+	return nil
+}
+
+// MarshalJSON writes the actions back in the wire format.
+func (l ActionList) MarshalJSON() ([]byte, error) {
+	items := make([]interface{}, 0, len(l))
+	for _, action := range l {
+		if a, ok := action.(*UnknownAction); ok && a.Type == "" {
+			items = append(items, a.Raw)
+			continue
+		}
+		var value interface{}
+		switch a := action.(type) {
+		case *SendMessageAction:
+			value = a.Message
+		case *ReplyButtonAction:
+			var button replyButtonJSON
+			button.Type = "button"
+			button.Body.Text = a.Body
+			for _, b := range a.Buttons {
+				button.Action.Buttons = append(button.Action.Buttons, struct {
+					Type  string      `json:"type"`
+					Reply ReplyButton `json:"reply"`
+				}{Type: "reply", Reply: b})
+			}
+			value = button
+		case *ListAction:
+			var list listJSON
+			list.Header, list.Body, list.Footer = a.Header, a.Body, a.Footer
+			list.Action.Button, list.Action.Sections = a.Button, a.Sections
+			value = list
+		case *MediaAction:
+			value = a.Files
+		case *UnknownAction:
+			value = a.Raw
+		default:
+			return nil, fmt.Errorf("unsupported action %T", action)
+		}
+		items = append(items, map[string]interface{}{action.ActionType(): value})
+	}
+	return json.Marshal(items)
+}
+
+// decodeAction decodes the payload of an action, falling back to
+// an *UnknownAction when it does not have the expected shape.
+func decodeAction(key string, raw json.RawMessage) Action {
+	action, err := decodeKnownAction(key, raw)
+	if err != nil || action == nil {
+		return &UnknownAction{Type: key, Raw: append(json.RawMessage(nil), raw...)}
+	}
+	return action
+}
+
+// decodeKnownAction decodes the actions of this package. It
+// returns nil for other keys.
+func decodeKnownAction(key string, raw json.RawMessage) (Action, error) {
+	switch key {
+	case ActionSendMessage:
+		var message []string
+		if err := json.Unmarshal(raw, &message); err != nil {
+			var single string
+			if json.Unmarshal(raw, &single) != nil {
+				return nil, err
+			}
+			message = []string{single}
+		}
+		return &SendMessageAction{Message: message}, nil
+	case ActionSendReplyButton:
+		var button replyButtonJSON
+		if err := json.Unmarshal(raw, &button); err != nil {
+			return nil, err
+		}
+		action := &ReplyButtonAction{Body: button.Body.Text}
+		for _, b := range button.Action.Buttons {
+			action.Buttons = append(action.Buttons, b.Reply)
+		}
+		return action, nil
+	case ActionSendButton:
+		var list listJSON
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, err
+		}
+		return &ListAction{
+			Header:   list.Header,
+			Body:     list.Body,
+			Footer:   list.Footer,
+			Button:   list.Action.Button,
+			Sections: list.Action.Sections,
+		}, nil
+	case ActionSendImages, ActionSendVideos, ActionSendAudios, ActionSendDocuments, ActionSendStickers:
+		var files []MediaFile
+		if err := json.Unmarshal(raw, &files); err != nil {
+			return nil, err
+		}
+		return &MediaAction{Type: key, Files: files}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,144 @@
+package sarufi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestActionListDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions string
+		want    ActionList
+	}{
+		{
+			"messages",
+			`[{"send_message": ["Hi", "How can I help?"]}, {"send_message": "Bye"}]`,
+			ActionList{&SendMessageAction{Message: []string{"Hi", "How can I help?"}}, &SendMessageAction{Message: []string{"Bye"}}},
+		},
+		{
+			"reply buttons",
+			`[{"send_reply_button": {"type": "button", "body": {"text": "Size?"}, "action": {"buttons": [{"type": "reply", "reply": {"id": "s", "title": "Small"}}]}}}]`,
+			ActionList{&ReplyButtonAction{Body: "Size?", Buttons: []ReplyButton{{ID: "s", Title: "Small"}}}},
+		},
+		{
+			"images",
+			`[{"send_images": [{"link": "https://example.com/pizza.png", "caption": "Pizza"}]}]`,
+			ActionList{&MediaAction{Type: ActionSendImages, Files: []MediaFile{{Link: "https://example.com/pizza.png", Caption: "Pizza"}}}},
+		},
+		{
+			"several keys in one item",
+			`[{"send_message": ["Hi"], "send_audios": []}]`,
+			ActionList{&MediaAction{Type: ActionSendAudios, Files: []MediaFile{}}, &SendMessageAction{Message: []string{"Hi"}}},
+		},
+		{
+			"unknown key",
+			`[{"send_location": {"lat": 1}}]`,
+			ActionList{&UnknownAction{Type: "send_location", Raw: json.RawMessage(`{"lat": 1}`)}},
+		},
+		{
+			"mixed message payload",
+			`[{"send_message": ["hi", {"x": 1}]}, {"send_message": ["ok"]}]`,
+			ActionList{
+				&UnknownAction{Type: ActionSendMessage, Raw: json.RawMessage(`["hi", {"x": 1}]`)},
+				&SendMessageAction{Message: []string{"ok"}},
+			},
+		},
+		{
+			"images as an object",
+			`[{"send_images": {"link": "https://example.com/pizza.png"}}]`,
+			ActionList{&UnknownAction{Type: ActionSendImages, Raw: json.RawMessage(`{"link": "https://example.com/pizza.png"}`)}},
+		},
+		{
+			"items that are not objects",
+			`["hi", {"send_message": ["ok"]}, null, 3]`,
+			ActionList{
+				&UnknownAction{Raw: json.RawMessage(`"hi"`)},
+				&SendMessageAction{Message: []string{"ok"}},
+				&UnknownAction{Raw: json.RawMessage(`null`)},
+				&UnknownAction{Raw: json.RawMessage(`3`)},
+			},
+		},
+		{
+			"buttons as a string",
+			`[{"send_reply_button": "Size?"}]`,
+			ActionList{&UnknownAction{Type: ActionSendReplyButton, Raw: json.RawMessage(`"Size?"`)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ActionList
+			if err := json.Unmarshal([]byte(tt.actions), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("got %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestReplyWithMalformedAction(t *testing.T) {
+	var reply Reply
+	data := `{"actions": [{"send_message": ["Hello", {"x": 1}]}, {"send_message": ["Pick a size"]}], "next_state": "size"}`
+	if err := json.Unmarshal([]byte(data), &reply); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Pick a size"}; !reflect.DeepEqual(reply.Message, want) {
+		t.Errorf("Message = %q, want %q", reply.Message, want)
+	}
+	if len(reply.Actions) != 2 || reply.NextState != "size" {
+		t.Errorf("reply %+v", reply)
+	}
+
+	// The unknown action is sent back as it came.
+	encoded, err := json.Marshal(reply.Actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"send_message":["Hello",{"x":1}]},{"send_message":["Pick a size"]}]`; string(encoded) != want {
+		t.Errorf("encoded %s, want %s", encoded, want)
+	}
+}
+
+func TestReplyWithItemNotAnObject(t *testing.T) {
+	var reply Reply
+	data := `{"actions": ["oops", {"send_message": ["Pick a size"]}], "next_state": "size"}`
+	if err := json.Unmarshal([]byte(data), &reply); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Pick a size"}; !reflect.DeepEqual(reply.Message, want) {
+		t.Errorf("Message = %q, want %q", reply.Message, want)
+	}
+	encoded, err := json.Marshal(reply.Actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["oops",{"send_message":["Pick a size"]}]`; string(encoded) != want {
+		t.Errorf("encoded %s, want %s", encoded, want)
+	}
+}
+
+func TestRespondKnowledgeBase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"actions": [{"send_message": ["Hi", {"x": 1}]}, {"send_images": [{"link": "a.png"}]}], "memory": {}, "next_state": "end"}`))
+	}))
+	defer server.Close()
+
+	bot := &Bot{Id: 1, ChatID: "chat"}
+	bot.SetClient(NewClient(WithAPIKey("key"), WithBaseURL(server.URL)))
+	if err := bot.Respond("hello", DefaultChannel); err != nil {
+		t.Fatal(err)
+	}
+	if bot.Conversation.NextState != "end" {
+		t.Errorf("Conversation = %+v", bot.Conversation)
+	}
+	if got := bot.ConversationWithKnowledge.Message; len(got) != 2 || len(got[0].ResponseMessage) != 2 {
+		t.Errorf("ConversationWithKnowledge = %+v", bot.ConversationWithKnowledge)
+	}
+}
@@ -1,9 +1,11 @@
 // Golang SDK for Sarufi Conversational AI Platform
 package sarufi
 
-// GetToken() method to generate a token for the user.
-// The received token will be saved to Application.Token
-// otherwise it will return an error.
+// SetToken() method to set the API key of the application.
+// It only affects the client of this application, other
+// applications and clients keep their own keys. It is safe to
+// call while requests are in flight; they use the old or the new
+// key.
 func (app *Application) SetToken(apiKey string) {
-	token = apiKey
+	app.Client().setKey(apiKey)
 }
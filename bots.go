@@ -2,6 +2,7 @@ package sarufi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -105,15 +106,23 @@ func (bot *Bot) CreateFlows(flows string) error {
 }
 
 // AddFlow method to add a new flow. It accepts a string
-// that will be the title of the flow and an interface.
-// To allow ability to add choices, flow has been made an
-// interface.
+// that will be the title of the flow and the flow itself.
+// The flow can be a Flow, a FlowState, a ChoiceState (or
+// pointers to them), or any value or JSON string in the
+// Sarufi flow format.
 // See: https://docs.sarufi.io/docs/Getting%20started%20/chatbots-addons#handling-choices
 func (bot *Bot) AddFlow(node string, flow interface{}) error {
 	if bot.Id == 0 {
 		return fmt.Errorf("No bot exists")
 	}
-	bot.Flows[node] = flow
+	newFlow, err := toFlow(flow)
+	if err != nil {
+		return err
+	}
+	if bot.Flows == nil {
+		bot.Flows = make(Flows)
+	}
+	bot.Flows[node] = newFlow
 	return nil
 }
 
@@ -129,264 +138,186 @@ func (bot *Bot) DeleteFlow(title string) error {
 }
 
 // To get bot responses from the API. It accepts a message to
-// responded to and a channel. The default channel is 'general'
+// responded to and a channel. The default channel is 'general'.
+// The reply is stored at bot.Conversation, and for knowledge base
+// bots at bot.ConversationWithKnowledge too. Use bot.Session to
+// get it as a Reply value instead.
 // See: https://neurotech-africa.stoplight.io/docs/sarufi/4a3ab3e807c34-handle-conversation
 func (bot *Bot) Respond(message, channel string) error {
+	return bot.RespondContext(context.Background(), message, channel)
+}
+
+// RespondContext is like Respond but uses ctx for the request.
+func (bot *Bot) RespondContext(ctx context.Context, message, channel string) error {
+	return bot.SendMessage(ctx, TextMessage(message), channel)
+}
+
+// SendMessage is like RespondContext for any kind of Message,
+// such as a button reply or a location. The reply is stored the
+// same way.
+func (bot *Bot) SendMessage(ctx context.Context, message Message, channel string) error {
 	if bot.Id == 0 {
 		return fmt.Errorf("No bot exists")
 	}
-	url := baseURL + "conversation/"
 
 	if bot.ChatID == "" {
 		bot.ChatID = uuid.New().String()
 	}
 
-	params := map[string]interface{}{
-		"chat_id":      bot.ChatID,
-		"bot_id":       bot.Id,
-		"message":      message,
-		"message_type": "text",
-		"channel":      channel,
-	}
-
-	jsonParams, err := json.Marshal(params)
-
+	reply, body, err := sendMessage(ctx, bot.apiClient(), bot.Id, bot.ChatID, message, channel)
 	if err != nil {
 		return err
 	}
-	statusCode, body, err := makeRequest("POST", url, bytes.NewBuffer(jsonParams))
 
-	if err != nil {
+	// Decode both views of the reply before storing either, so a
+	// failure leaves the bot as it was.
+	var knowledge ConversationWithKnowledge
+	if err := json.Unmarshal(body, &knowledge); err != nil {
 		return err
 	}
-	switch statusCode {
-	case 200:
-		if bot.ModelName == "" {
-			if err := json.Unmarshal(body, &bot.ConversationWithKnowledge); err != nil {
-				return err
-			}
-			return nil
-		}
-
-		if err := json.Unmarshal(body, &bot.Conversation); err != nil {
-			return err
-		}
-		return nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return fmt.Errorf(string(body))
+	bot.Conversation = Conversation{
+		Message:   reply.Message,
+		Memory:    reply.Memory,
+		NextState: reply.NextState,
 	}
+	bot.ConversationWithKnowledge = knowledge
+	return nil
 }
 
 // To get the current and next state of the chat. It
 // accepts no parameters. It will display the JSON
 // response from the API.
 func (bot *Bot) ChatState() error {
-	if bot.Id == 0 {
-		return fmt.Errorf("No bot exists")
-	}
-	url := baseURL + "conversation/status"
-	params := map[string]interface{}{
-		"chat_id": bot.ChatID,
-		"bot_id":  bot.Id,
-	}
-
-	jsonParams, err := json.Marshal(params)
+	return bot.ChatStateContext(context.Background())
+}
 
+// ChatStateContext is like ChatState but uses ctx for the request.
+func (bot *Bot) ChatStateContext(ctx context.Context) error {
+	state, err := bot.GetState(ctx, bot.ChatID)
 	if err != nil {
-		return nil
+		return err
 	}
-	statusCode, body, err := makeRequest("POST", url, bytes.NewBuffer(jsonParams))
+	bot.Conversation.Memory = state.Memory
+	bot.Conversation.CurrentState = state.CurrentState
+	bot.Conversation.NextState = state.NextState
+	return nil
+}
 
-	if err != nil {
-		return nil
-	}
-	switch statusCode {
-	case 200:
-		if err := json.Unmarshal(body, &bot.Conversation); err != nil {
-			return err
-		}
-		return nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return fmt.Errorf(string(body))
+// GetState returns the current and next state of a chat without
+// storing them on the bot, so it is safe to call from several
+// goroutines.
+func (bot *Bot) GetState(ctx context.Context, chatID string) (Conversation, error) {
+	if bot.Id == 0 {
+		return Conversation{}, fmt.Errorf("No bot exists")
 	}
+	return fetchChatState(ctx, bot.apiClient(), bot.Id, chatID)
 }
 
 // A method to predict the intent of a particular message. It will return
 // an error if any. The result will be stored at the bot.Prediction field.
 func (bot *Bot) Predict(message string) error {
+	return bot.PredictContext(context.Background(), message)
+}
+
+// PredictContext is like Predict but uses ctx for the request.
+func (bot *Bot) PredictContext(ctx context.Context, message string) error {
+	prediction, err := bot.PredictIntent(ctx, message)
+	if err != nil {
+		return err
+	}
+	bot.Prediction = prediction
+	return nil
+}
+
+// PredictIntent asks the API for the intent of a message and
+// returns the prediction without storing it on the bot, so it is
+// safe to call from several goroutines. It makes Bot an
+// IntentPredictor.
+func (bot *Bot) PredictIntent(ctx context.Context, message string) (Prediction, error) {
 	if bot.Id == 0 {
-		return fmt.Errorf("No bot exists")
+		return Prediction{}, fmt.Errorf("No bot exists")
 	}
-	bot.Prediction.Message = message
-	url := baseURL + "predict/intent"
+	client := bot.apiClient()
+	url := client.url("predict/intent")
 	params := map[string]interface{}{
-		"message": bot.Prediction.Message,
+		"message": message,
 		"bot_id":  bot.Id,
 	}
 
 	jsonParams, err := json.Marshal(params)
 
 	if err != nil {
-		return nil
+		return Prediction{}, err
 	}
-	statusCode, body, err := makeRequest("POST", url, bytes.NewBuffer(jsonParams))
+	body, err := client.makeRequest(ctx, "POST", url, bytes.NewBuffer(jsonParams), idempotent(), forBot(bot.Id))
 
 	if err != nil {
-		return nil
+		return Prediction{}, err
 	}
-	switch statusCode {
-	case 200:
-		if err := json.Unmarshal(body, &bot.Prediction); err != nil {
-			return err
-		}
-		return nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return fmt.Errorf(string(body))
+	prediction := Prediction{Message: message}
+	if err := json.Unmarshal(body, &prediction); err != nil {
+		return Prediction{}, err
 	}
+	return prediction, nil
 }
 
 // A method to get all users communicating with the bot.
 // A list of chat users will be stored at bot.ChatUsers field.
 func (bot *Bot) GetChatUsers() error {
+	return bot.GetChatUsersContext(context.Background())
+}
+
+// GetChatUsersContext is like GetChatUsers but uses ctx for the request.
+func (bot *Bot) GetChatUsersContext(ctx context.Context) error {
+	users, err := bot.ListChatUsers(ctx)
+	if err != nil {
+		return err
+	}
+	bot.ChatUsers = users
+	return nil
+}
+
+// ListChatUsers returns all users communicating with the bot
+// without storing them on the bot.
+func (bot *Bot) ListChatUsers(ctx context.Context) ([]ChatUser, error) {
 	if bot.Id == 0 {
-		return fmt.Errorf("No bot exists")
+		return nil, fmt.Errorf("No bot exists")
 	}
-	url := fmt.Sprintf("%schatbot/%d/users", baseURL, bot.Id)
-	statusCode, body, err := makeRequest("GET", url, nil)
+	client := bot.apiClient()
+	url := client.url(fmt.Sprintf("chatbot/%d/users", bot.Id))
+	body, err := client.makeRequest(ctx, "GET", url, nil, forBot(bot.Id))
 
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	switch statusCode {
-	case 200:
-		if err := json.Unmarshal(body, &bot.ChatUsers); err != nil {
-			return err
-		}
-		return nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return fmt.Errorf(string(body))
+	var users []ChatUser
+	if err := json.Unmarshal(body, &users); err != nil {
+		return nil, err
 	}
+	return users, nil
 }
 
 // Get conversation history of a particular ChatID. The result
 // will be stored at the bot.ConversationHistory.
 func (bot *Bot) GetChatHistory(chatID string) error {
-	if bot.Id == 0 {
-		return fmt.Errorf("No bot exists")
-	}
-	url := fmt.Sprintf("%sconversation/history/%d/%s", baseURL, bot.Id, chatID)
-	statusCode, body, err := makeRequest("GET", url, nil)
+	return bot.GetChatHistoryContext(context.Background(), chatID)
+}
 
+// GetChatHistoryContext is like GetChatHistory but uses ctx for the request.
+func (bot *Bot) GetChatHistoryContext(ctx context.Context, chatID string) error {
+	history, err := bot.ChatHistory(ctx, chatID)
 	if err != nil {
-		return nil
+		return err
 	}
-	switch statusCode {
-	case 200:
-		if err := json.Unmarshal(body, &bot); err != nil {
-			return err
-		}
-		return nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return fmt.Errorf(string(body))
+	bot.ConversationHistory = history
+	return nil
+}
+
+// ChatHistory returns the conversation history of a chat without
+// storing it on the bot. Other fields of the bot are left as is.
+func (bot *Bot) ChatHistory(ctx context.Context, chatID string) ([]ConversationHistory, error) {
+	if bot.Id == 0 {
+		return nil, fmt.Errorf("No bot exists")
 	}
+	return fetchChatHistory(ctx, bot.apiClient(), bot.Id, chatID)
 }
@@ -0,0 +1,169 @@
+package sarufi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// IntentPredictor predicts the intent of a message. It is
+// implemented by Bot, which asks the API, and by LocalClassifier,
+// which runs offline.
+type IntentPredictor interface {
+	PredictIntent(ctx context.Context, message string) (Prediction, error)
+}
+
+// LocalClassifier predicts intents offline from the example
+// messages of a bot. Messages are compared with TF-IDF weighted
+// word and character trigram vectors and the intent of the most
+// similar example wins, its cosine similarity being the confidence.
+// A LocalClassifier is read-only once built and safe for
+// concurrent use.
+type LocalClassifier struct {
+	idf      map[string]float64
+	examples []classifierExample
+}
+
+type classifierExample struct {
+	intent string
+	vector map[string]float64
+}
+
+// NewLocalClassifier trains a classifier on the given intents,
+// usually Bot.Intents.
+func NewLocalClassifier(intents map[string][]string) *LocalClassifier {
+	names := make([]string, 0, len(intents))
+	for name := range intents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var counts []map[string]float64
+	var owners []string
+	df := make(map[string]float64)
+	for _, name := range names {
+		for _, example := range intents[name] {
+			tf := termCounts(example)
+			if len(tf) == 0 {
+				continue
+			}
+			for term := range tf {
+				df[term]++
+			}
+			counts = append(counts, tf)
+			owners = append(owners, name)
+		}
+	}
+
+	c := &LocalClassifier{idf: make(map[string]float64, len(df))}
+	n := float64(len(counts))
+	for term, count := range df {
+		c.idf[term] = math.Log((1+n)/(1+count)) + 1
+	}
+	for i, tf := range counts {
+		c.examples = append(c.examples, classifierExample{intent: owners[i], vector: c.weigh(tf)})
+	}
+	return c
+}
+
+// PredictIntent returns the intent of the example most similar to
+// the message. Status is false when no example shares anything
+// with the message.
+func (c *LocalClassifier) PredictIntent(ctx context.Context, message string) (Prediction, error) {
+	if err := ctx.Err(); err != nil {
+		return Prediction{}, err
+	}
+
+	prediction := Prediction{Message: message}
+	vector := c.weigh(termCounts(message))
+	for _, example := range c.examples {
+		if score := cosine(vector, example.vector); score > prediction.Confidence {
+			prediction.Intent = example.intent
+			prediction.Confidence = score
+		}
+	}
+	prediction.Status = prediction.Intent != ""
+	return prediction, nil
+}
+
+// weigh turns term counts into a unit length TF-IDF vector.
+// Terms unknown to the classifier are dropped.
+func (c *LocalClassifier) weigh(tf map[string]float64) map[string]float64 {
+	vector := make(map[string]float64, len(tf))
+	var norm float64
+	for term, count := range tf {
+		idf, ok := c.idf[term]
+		if !ok {
+			continue
+		}
+		weight := count * idf
+		vector[term] = weight
+		norm += weight * weight
+	}
+	norm = math.Sqrt(norm)
+	for term := range vector {
+		vector[term] /= norm
+	}
+	return vector
+}
+
+// termCounts splits text into words and character trigrams of
+// each word, padded with spaces, and counts them.
+func termCounts(text string) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, word := range strings.Fields(normalizeExample(text)) {
+		word = strings.TrimFunc(word, isPunctuation)
+		if word == "" {
+			continue
+		}
+		counts["w:"+word]++
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			counts["c:"+string(padded[i:i+3])]++
+		}
+	}
+	return counts
+}
+
+func isPunctuation(r rune) bool {
+	return strings.ContainsRune(".,!?;:'\"()", r)
+}
+
+func cosine(a, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var dot float64
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+	return dot
+}
+
+// FallbackPredictor returns a predictor that asks primary first
+// and falls back to fallback when primary fails with a network
+// error or a 5xx response, e.g. a Bot backed by a LocalClassifier
+// for when the API is down. Other errors are returned as is.
+func FallbackPredictor(primary, fallback IntentPredictor) IntentPredictor {
+	return &fallbackPredictor{primary: primary, fallback: fallback}
+}
+
+type fallbackPredictor struct {
+	primary  IntentPredictor
+	fallback IntentPredictor
+}
+
+func (p *fallbackPredictor) PredictIntent(ctx context.Context, message string) (Prediction, error) {
+	prediction, err := p.primary.PredictIntent(ctx, message)
+	if err == nil || ctx.Err() != nil {
+		return prediction, err
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError {
+		return prediction, err
+	}
+	return p.fallback.PredictIntent(ctx, message)
+}
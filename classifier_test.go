@@ -0,0 +1,83 @@
+package sarufi
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLocalClassifier(t *testing.T) {
+	classifier := NewLocalClassifier(map[string][]string{
+		"greets":      {"hey", "hello", "hi", "good morning"},
+		"goodbye":     {"bye", "goodbye", "see ya", "see you later"},
+		"order_pizza": {"I need pizza", "I want pizza", "order a pizza please"},
+		"track_order": {"where is my order", "track my delivery", "has my order shipped"},
+	})
+
+	tests := []struct {
+		message string
+		intent  string
+	}{
+		{"hello", "greets"},
+		{"Hello!", "greets"},
+		{"good morning to you", "greets"},
+		{"bye bye", "goodbye"},
+		{"see you", "goodbye"},
+		{"i want a pizza", "order_pizza"},
+		{"PIZZA please", "order_pizza"},
+		{"where is my delivery", "track_order"},
+		{"track order", "track_order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			prediction, err := classifier.PredictIntent(context.Background(), tt.message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prediction.Intent != tt.intent {
+				t.Errorf("intent %q (%.2f), want %q", prediction.Intent, prediction.Confidence, tt.intent)
+			}
+			if !prediction.Status || prediction.Confidence <= 0 || prediction.Confidence > 1+1e-9 {
+				t.Errorf("status %v, confidence %v", prediction.Status, prediction.Confidence)
+			}
+		})
+	}
+}
+
+func TestLocalClassifierNoMatch(t *testing.T) {
+	classifier := NewLocalClassifier(map[string][]string{"greets": {"hello"}})
+
+	prediction, err := classifier.PredictIntent(context.Background(), "zzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prediction.Status || prediction.Confidence != 0 {
+		t.Errorf("prediction %+v for an unrelated message", prediction)
+	}
+
+	empty := NewLocalClassifier(nil)
+	if prediction, err := empty.PredictIntent(context.Background(), "hello"); err != nil || prediction.Status {
+		t.Errorf("empty classifier: %+v, %v", prediction, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := classifier.PredictIntent(ctx, "hello"); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v with a cancelled context", err)
+	}
+}
+
+func TestLocalClassifierExactExample(t *testing.T) {
+	classifier := NewLocalClassifier(pizzaIntents)
+	for intent, examples := range pizzaIntents {
+		for _, example := range examples {
+			prediction, err := classifier.PredictIntent(context.Background(), example)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prediction.Intent != intent {
+				t.Errorf("%q: intent %q, want %q", example, prediction.Intent, intent)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package sarufi
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBaseURL   = "https://developers.sarufi.io/"
+	defaultUserAgent = "sarufi-golang-sdk"
+)
+
+// Client holds everything needed to talk to the Sarufi API:
+// the API key, the base URL and the underlying http.Client.
+// Every Application and Bot method routes its requests through
+// a Client, so one process can hold as many of them as needed,
+// one per account or server.
+type Client struct {
+	// keyMu guards apiKey, which SetToken may change while
+	// requests are made.
+	keyMu  sync.RWMutex
+	apiKey string
+
+	baseURL    string
+	userAgent  string
+	timeout    time.Duration
+	httpClient *http.Client
+
+	retryPolicy RetryPolicy
+	limiter     rateLimiter
+}
+
+// Option configures a Client. See NewClient.
+type Option func(*Client)
+
+// WithAPIKey sets the API key sent as a bearer token on every request.
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithBaseURL points the client at a different Sarufi server,
+// for example a staging environment or a local fake.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the http.Client used to perform requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent on every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTimeout sets the overall timeout of a single request. The
+// http.Client passed with WithHTTPClient is copied, not modified.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// NewClient returns a new Client configured with the given options.
+// Without options it talks to https://developers.sarufi.io/ with
+// no API key.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		baseURL:     defaultBaseURL,
+		userAgent:   defaultUserAgent,
+		httpClient:  &http.Client{},
+		retryPolicy: DefaultRetryPolicy(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{}
+	}
+	if c.timeout > 0 {
+		httpClient := *c.httpClient
+		httpClient.Timeout = c.timeout
+		c.httpClient = &httpClient
+	}
+	if !strings.HasSuffix(c.baseURL, "/") {
+		c.baseURL += "/"
+	}
+	return c
+}
+
+// NewApplication returns an Application whose requests go through
+// a new Client configured with the given options.
+func NewApplication(opts ...Option) *Application {
+	return &Application{client: NewClient(opts...)}
+}
+
+// Client returns the client used by the application. A zero
+// Application gets a default client on first use.
+func (app *Application) Client() *Client {
+	if app.client == nil {
+		app.client = NewClient()
+	}
+	return app.client
+}
+
+// SetClient attaches a client to the bot. Bots returned by
+// Application methods already carry the application's client,
+// this is only needed for bots built by hand.
+func (bot *Bot) SetClient(client *Client) {
+	bot.client = client
+}
+
+var (
+	defaultClientOnce sync.Once
+	defaultClient     *Client
+)
+
+// apiClient returns the client attached to the bot, or an
+// unauthenticated default one shared by all bots without a
+// client, so they share its rate limits. It does not modify the
+// bot so it is safe to call from several goroutines.
+func (bot *Bot) apiClient() *Client {
+	if bot.client == nil {
+		defaultClientOnce.Do(func() {
+			defaultClient = NewClient()
+		})
+		return defaultClient
+	}
+	return bot.client
+}
+
+// key returns the API key of the client.
+func (c *Client) key() string {
+	c.keyMu.RLock()
+	defer c.keyMu.RUnlock()
+	return c.apiKey
+}
+
+// setKey replaces the API key of the client.
+func (c *Client) setKey(apiKey string) {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	c.apiKey = apiKey
+}
+
+// url joins the client's base URL with the given path.
+func (c *Client) url(path string) string {
+	return c.baseURL + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,51 @@
+package sarufi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestSetTokenWhileRequesting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer old" && auth != "Bearer new" {
+			t.Errorf("Authorization: %q", auth)
+		}
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+	app := NewApplication(WithAPIKey("old"), WithBaseURL(server.URL))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := app.GetBotContext(context.Background(), 1); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	for j := 0; j < 10; j++ {
+		app.SetToken("new")
+		app.SetToken("old")
+	}
+	wg.Wait()
+}
+
+func TestDefaultBotClient(t *testing.T) {
+	a, b := (&Bot{}).apiClient(), (&Bot{}).apiClient()
+	if a != b {
+		t.Error("bots without a client get different default clients")
+	}
+	client := NewClient()
+	bot := &Bot{}
+	bot.SetClient(client)
+	if bot.apiClient() != client {
+		t.Error("bot does not use its own client")
+	}
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/internal/strs"
+)
+
+// botView is what is printed of a bot, without the runtime fields.
+type botView struct {
+	ID                    int                 `json:"id"`
+	Name                  string              `json:"name"`
+	Description           string              `json:"description"`
+	Industry              string              `json:"industry"`
+	VisibleOnCommunity    bool                `json:"visible_on_community"`
+	ModelName             string              `json:"model_name,omitempty"`
+	WebhookURL            string              `json:"webhook_url,omitempty"`
+	WebhookTriggerIntents []string            `json:"webhook_trigger_intents,omitempty"`
+	Intents               map[string][]string `json:"intents"`
+	Flows                 sarufi.Flows        `json:"flows"`
+}
+
+func viewBot(bot *sarufi.Bot) botView {
+	return botView{
+		ID:                    bot.Id,
+		Name:                  bot.Name,
+		Description:           bot.Description,
+		Industry:              bot.Industry,
+		VisibleOnCommunity:    bot.VisibleOnCommunity,
+		ModelName:             bot.ModelName,
+		WebhookURL:            bot.WebhookURL,
+		WebhookTriggerIntents: bot.WebhookTriggerIntents,
+		Intents:               bot.Intents,
+		Flows:                 bot.Flows,
+	}
+}
+
+func (c *cli) printBot(bot *sarufi.Bot) error {
+	t := &table{}
+	t.add("ID", bot.Id)
+	t.add("NAME", bot.Name)
+	t.add("DESCRIPTION", bot.Description)
+	t.add("INDUSTRY", bot.Industry)
+	t.add("VISIBLE", bot.VisibleOnCommunity)
+	t.add("MODEL", bot.ModelName)
+	t.add("WEBHOOK", bot.WebhookURL)
+	t.add("WEBHOOK INTENTS", strings.Join(bot.WebhookTriggerIntents, ", "))
+	t.add("INTENTS", strings.Join(strs.SortedKeys(bot.Intents), ", "))
+	t.add("FLOWS", strings.Join(strs.SortedKeys(bot.Flows), ", "))
+	return c.print(viewBot(bot), t)
+}
+
+func botsCommand(ctx context.Context, c *cli, args []string) error {
+	const usage = "bots list|get|create|update|delete"
+	if len(args) == 0 {
+		return usageError(usage)
+	}
+	switch args[0] {
+	case "list":
+		return botsList(ctx, c, args[1:])
+	case "get":
+		return botsGet(ctx, c, args[1:])
+	case "create":
+		return botsCreate(ctx, c, args[1:])
+	case "update":
+		return botsUpdate(ctx, c, args[1:])
+	case "delete":
+		return botsDelete(ctx, c, args[1:])
+	}
+	return usageError(usage)
+}
+
+func botsList(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 0 {
+		return usageError("bots list")
+	}
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+	bots, err := app.GetAllBotsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	views := make([]botView, 0, len(bots))
+	t := &table{header: []string{"ID", "NAME", "INDUSTRY", "VISIBLE", "INTENTS", "FLOWS"}}
+	for i := range bots {
+		bot := &bots[i]
+		views = append(views, viewBot(bot))
+		t.add(bot.Id, bot.Name, bot.Industry, bot.VisibleOnCommunity, len(bot.Intents), len(bot.Flows))
+	}
+	return c.print(views, t)
+}
+
+func botsGet(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 1 {
+		return usageError("bots get <bot-id>")
+	}
+	bot, err := c.getBot(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return c.printBot(bot)
+}
+
+// botFlags are the flags setting the details of a bot.
+type botFlags struct {
+	name, description, industry string
+	visible                     bool
+	webhookURL, webhookIntents  string
+	intents, flows              string
+}
+
+func (f *botFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.name, "name", "", "name of the bot")
+	fs.StringVar(&f.description, "description", "", "description of the bot")
+	fs.StringVar(&f.industry, "industry", "", "industry of the bot")
+	fs.BoolVar(&f.visible, "visible", false, "show the bot on the community")
+	fs.StringVar(&f.webhookURL, "webhook-url", "", "webhook URL")
+	fs.StringVar(&f.webhookIntents, "webhook-intents", "", "comma separated intents triggering the webhook")
+	fs.StringVar(&f.intents, "intents", "", "JSON file with the intents, - for stdin")
+	fs.StringVar(&f.flows, "flows", "", "JSON file with the flows, - for stdin")
+}
+
+// apply sets the flags given on the command line on the bot.
+func (f *botFlags) apply(c *cli, fs *flag.FlagSet, bot *sarufi.Bot) error {
+	set := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+
+	if set["name"] {
+		bot.Name = f.name
+	}
+	if set["description"] {
+		bot.Description = f.description
+	}
+	if set["industry"] {
+		bot.Industry = f.industry
+	}
+	if set["visible"] {
+		bot.VisibleOnCommunity = f.visible
+	}
+	if set["intents"] {
+		data, err := c.readFile(f.intents)
+		if err != nil {
+			return err
+		}
+		if err := bot.CreateIntents(string(data)); err != nil {
+			return err
+		}
+	}
+	if set["flows"] {
+		data, err := c.readFile(f.flows)
+		if err != nil {
+			return err
+		}
+		if err := bot.CreateFlows(string(data)); err != nil {
+			return err
+		}
+	}
+	if set["webhook-url"] || set["webhook-intents"] {
+		webhookURL := bot.WebhookURL
+		if set["webhook-url"] {
+			webhookURL = f.webhookURL
+		}
+		intents := bot.WebhookTriggerIntents
+		if set["webhook-intents"] {
+			intents = splitList(f.webhookIntents)
+		}
+		if err := bot.ConfigureWebhook(webhookURL, intents...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func botsCreate(ctx context.Context, c *cli, args []string) error {
+	var f botFlags
+	fs := c.flagSet("bots create")
+	f.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return usageError("bots create -name <name> [flags]")
+	}
+	if f.name == "" || fs.NArg() != 0 {
+		return usageError("bots create -name <name> [flags]")
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+	bot, err := app.CreateBotContext(ctx, f.name, f.description, f.industry, f.visible)
+	if err != nil {
+		return err
+	}
+	if f.intents != "" || f.flows != "" || f.webhookURL != "" || f.webhookIntents != "" {
+		if err := f.apply(c, fs, bot); err != nil {
+			return fmt.Errorf("bot %d was created but not configured: %w", bot.Id, err)
+		}
+		if err := app.UpdateBotContext(ctx, bot); err != nil {
+			return fmt.Errorf("bot %d was created but not configured: %w", bot.Id, err)
+		}
+	}
+	return c.printBot(bot)
+}
+
+func botsUpdate(ctx context.Context, c *cli, args []string) error {
+	const usage = "bots update <bot-id> [flags]"
+	if len(args) == 0 {
+		return usageError(usage)
+	}
+	var f botFlags
+	fs := c.flagSet("bots update")
+	f.register(fs)
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 0 {
+		return usageError(usage)
+	}
+
+	app, bot, err := c.fetchBot(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if err := f.apply(c, fs, bot); err != nil {
+		return err
+	}
+	if err := app.UpdateBotContext(ctx, bot); err != nil {
+		return err
+	}
+	return c.printBot(bot)
+}
+
+func botsDelete(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 1 {
+		return usageError("bots delete <bot-id>")
+	}
+	id, err := parseBotID(args[0])
+	if err != nil {
+		return err
+	}
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+	if err := app.DeleteBotContext(ctx, id); err != nil {
+		return err
+	}
+	if c.output == "table" {
+		fmt.Fprintf(c.stdout, "bot %d deleted\n", id)
+		return nil
+	}
+	return c.print(map[string]interface{}{"id": id, "deleted": true}, nil)
+}
+
+// definitionCommand returns the intents or flows command.
+func definitionCommand(kind string) command {
+	return func(ctx context.Context, c *cli, args []string) error {
+		usage := kind + " get <bot-id> | set <bot-id> <file> | delete <bot-id> <name>..."
+		if len(args) < 2 {
+			return usageError(usage)
+		}
+		switch {
+		case args[0] == "get" && len(args) == 2:
+			_, bot, err := c.fetchBot(ctx, args[1])
+			if err != nil {
+				return err
+			}
+			return c.printDefinition(kind, bot)
+		case args[0] == "set" && len(args) == 3:
+			app, bot, err := c.fetchBot(ctx, args[1])
+			if err != nil {
+				return err
+			}
+			data, err := c.readFile(args[2])
+			if err != nil {
+				return err
+			}
+			if kind == "intents" {
+				err = bot.CreateIntents(string(data))
+			} else {
+				err = bot.CreateFlows(string(data))
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", args[2], err)
+			}
+			if err := app.UpdateBotContext(ctx, bot); err != nil {
+				return err
+			}
+			return c.printDefinition(kind, bot)
+		case args[0] == "delete" && len(args) >= 3:
+			app, bot, err := c.fetchBot(ctx, args[1])
+			if err != nil {
+				return err
+			}
+			for _, name := range args[2:] {
+				if kind == "intents" {
+					err = bot.DeleteIntent(name)
+				} else {
+					err = bot.DeleteFlow(name)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if err := app.UpdateBotContext(ctx, bot); err != nil {
+				return err
+			}
+			return c.printDefinition(kind, bot)
+		}
+		return usageError(usage)
+	}
+}
+
+func (c *cli) printDefinition(kind string, bot *sarufi.Bot) error {
+	if kind == "intents" {
+		t := &table{header: []string{"INTENT", "EXAMPLES"}}
+		for _, name := range strs.SortedKeys(bot.Intents) {
+			t.add(name, strings.Join(bot.Intents[name], " | "))
+		}
+		return c.print(bot.Intents, t)
+	}
+
+	t := &table{header: []string{"STATE", "KIND", "TARGETS"}}
+	for _, name := range strs.SortedKeys(bot.Flows) {
+		flow := bot.Flows[name]
+		kind := "state"
+		if flow.IsChoice() {
+			kind = "choice"
+		}
+		t.add(name, kind, strings.Join(flow.Targets(), ", "))
+	}
+	return c.print(bot.Flows, t)
+}
+
+// getBot fetches the bot with the given ID.
+func (c *cli) getBot(ctx context.Context, value string) (*sarufi.Bot, error) {
+	_, bot, err := c.fetchBot(ctx, value)
+	return bot, err
+}
+
+// fetchBot is like getBot but also returns the application.
+func (c *cli) fetchBot(ctx context.Context, value string) (*sarufi.Application, *sarufi.Bot, error) {
+	id, err := parseBotID(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	app, err := c.application()
+	if err != nil {
+		return nil, nil, err
+	}
+	bot, err := app.GetBotContext(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return app, bot, nil
+}
+
+// botRef returns a bot with only the given ID, for commands that
+// need no more than that, saving the request fetching it.
+func (c *cli) botRef(value string) (*sarufi.Bot, error) {
+	id, err := parseBotID(value)
+	if err != nil {
+		return nil, err
+	}
+	app, err := c.application()
+	if err != nil {
+		return nil, err
+	}
+	bot := &sarufi.Bot{Id: id}
+	bot.SetClient(app.Client())
+	return bot, nil
+}
+
+func parseBotID(value string) (int, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: invalid bot ID %q", errUsage, value)
+	}
+	return id, nil
+}
+
+// readFile reads a file, or stdin if name is "-".
+func (c *cli) readFile(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(c.stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// readJSON decodes a JSON file, or stdin if name is "-", into v.
+func (c *cli) readJSON(name string, v interface{}) error {
+	data, err := c.readFile(name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// flagSet returns a flag set for a subcommand.
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+
+	"github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/repl"
+)
+
+func chatCommand(ctx context.Context, c *cli, args []string) error {
+	const usage = "chat [-chat-id <id>] <bot-id> | chat -offline [-intents <file> -flows <file>] [<bot-id>]"
+	fs := c.flagSet("chat")
+	offline := fs.Bool("offline", false, "run the bot locally instead of through the API")
+	chatID := fs.String("chat-id", "", "chat ID to continue (live only)")
+	intents := fs.String("intents", "", "JSON file with the intents (offline only)")
+	flows := fs.String("flows", "", "JSON file with the flows (offline only)")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 1 {
+		return usageError(usage)
+	}
+	local := *intents != "" || *flows != ""
+	if local && !*offline {
+		return usageError(usage)
+	}
+	if fs.NArg() == 0 && !local {
+		return usageError(usage)
+	}
+
+	var bot *sarufi.Bot
+	if fs.NArg() == 1 {
+		var err error
+		if bot, err = c.getBot(ctx, fs.Arg(0)); err != nil {
+			return err
+		}
+	} else {
+		bot = &sarufi.Bot{Name: "Local bot"}
+	}
+	if *intents != "" {
+		bot.Intents = nil
+		if err := c.readJSON(*intents, &bot.Intents); err != nil {
+			return err
+		}
+	}
+	if *flows != "" {
+		bot.Flows = nil
+		if err := c.readJSON(*flows, &bot.Flows); err != nil {
+			return err
+		}
+	}
+
+	var backend repl.Backend
+	if *offline {
+		backend = repl.Offline(bot)
+	} else {
+		backend = repl.Live(bot, *chatID)
+	}
+	r := repl.New(backend, c.stdin, c.stdout)
+	r.Title = bot.Name
+	return r.Run(ctx)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sarufi-io/sarufi-golang-sdk"
+)
+
+// command runs a subcommand with its arguments.
+type command func(ctx context.Context, c *cli, args []string) error
+
+var commands = map[string]command{
+	"bots":    botsCommand,
+	"chat":    chatCommand,
+	"intents": definitionCommand("intents"),
+	"flows":   definitionCommand("flows"),
+	"predict": predictCommand,
+	"history": historyCommand,
+	"users":   usersCommand,
+	"whoami":  whoamiCommand,
+	"plan":    planCommand,
+	"apply":   applyCommand,
+	"diff":    diffCommand,
+
+	"import-rasa": importRasaCommand,
+}
+
+func predictCommand(ctx context.Context, c *cli, args []string) error {
+	if len(args) < 2 {
+		return usageError("predict <bot-id> <message>")
+	}
+	bot, err := c.botRef(args[0])
+	if err != nil {
+		return err
+	}
+	prediction, err := bot.PredictIntent(ctx, strings.Join(args[1:], " "))
+	if err != nil {
+		return err
+	}
+	t := &table{header: []string{"INTENT", "CONFIDENCE", "MESSAGE"}}
+	t.add(prediction.Intent, prediction.Confidence, prediction.Message)
+	return c.print(prediction, t)
+}
+
+func historyCommand(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 2 {
+		return usageError("history <bot-id> <chat-id>")
+	}
+	bot, err := c.botRef(args[0])
+	if err != nil {
+		return err
+	}
+	history, err := bot.ChatHistory(ctx, args[1])
+	if err != nil {
+		return err
+	}
+	if history == nil {
+		history = []sarufi.ConversationHistory{}
+	}
+
+	t := &table{header: []string{"TIME", "SENDER", "MESSAGE", "RESPONSE"}}
+	for _, h := range history {
+		var response []string
+		for _, r := range h.Response {
+			response = append(response, r.Message...)
+		}
+		t.add(h.ReceivedTime, h.Sender, h.Message, strings.Join(response, " / "))
+	}
+	return c.print(history, t)
+}
+
+func usersCommand(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 1 {
+		return usageError("users <bot-id>")
+	}
+	bot, err := c.botRef(args[0])
+	if err != nil {
+		return err
+	}
+	users, err := bot.ListChatUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if users == nil {
+		users = []sarufi.ChatUser{}
+	}
+
+	t := &table{header: []string{"CHAT ID", "LAST MESSAGE"}}
+	for _, u := range users {
+		t.add(u.ChatID, u.ReceivedTime)
+	}
+	return c.print(users, t)
+}
+
+// userView is what is printed of a user, without the password.
+type userView struct {
+	ID          int    `json:"id"`
+	FullName    string `json:"full_name"`
+	Username    string `json:"username"`
+	Mobile      string `json:"mobile"`
+	IsAdmin     bool   `json:"is_admin"`
+	DateCreated string `json:"date_created"`
+}
+
+func whoamiCommand(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 0 {
+		return usageError("whoami")
+	}
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+	user, err := app.GetUserContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	view := userView{
+		ID:          user.ID,
+		FullName:    user.FullName,
+		Username:    user.Username,
+		Mobile:      user.Mobile,
+		IsAdmin:     user.IsAdmin,
+		DateCreated: user.DateCreated,
+	}
+	t := &table{}
+	t.add("ID", view.ID)
+	t.add("NAME", view.FullName)
+	t.add("USERNAME", view.Username)
+	t.add("MOBILE", view.Mobile)
+	t.add("ADMIN", view.IsAdmin)
+	t.add("CREATED", view.DateCreated)
+	return c.print(view, t)
+}
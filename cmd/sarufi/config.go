@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config is the content of the config file.
+type config struct {
+	APIKey  string `yaml:"api_key" json:"api_key"`
+	BaseURL string `yaml:"base_url" json:"base_url"`
+}
+
+// loadConfig reads the config file and applies the environment
+// variables SARUFI_API_KEY and SARUFI_BASE_URL on top of it. A
+// missing default config file is not an error.
+func loadConfig(path string) (config, error) {
+	var cfg config
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv("SARUFI_CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err == nil {
+			path = filepath.Join(dir, "sarufi", "config.yaml")
+		}
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				return cfg, fmt.Errorf("config file %s: %w", path, err)
+			}
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+		default:
+			return cfg, err
+		}
+	}
+
+	if key := os.Getenv("SARUFI_API_KEY"); key != "" {
+		cfg.APIKey = key
+	}
+	if baseURL := os.Getenv("SARUFI_BASE_URL"); baseURL != "" {
+		cfg.BaseURL = baseURL
+	}
+	return cfg, nil
+}
@@ -0,0 +1,173 @@
+// Command sarufi manages Sarufi bots from the command line.
+//
+// Usage:
+//
+//	sarufi [flags] <command> [arguments]
+//
+// The commands are:
+//
+//	bots list                  list your bots
+//	bots get <bot-id>          show a bot
+//	bots create [flags]        create a bot
+//	bots update <bot-id> [flags]
+//	                           change the details of a bot
+//	bots delete <bot-id>       delete a bot
+//	intents get <bot-id>       show the intents of a bot
+//	intents set <bot-id> <file>
+//	                           replace the intents of a bot with a JSON file
+//	intents delete <bot-id> <intent>...
+//	                           delete intents of a bot
+//	flows get|set|delete       the same for flows
+//	predict <bot-id> <message> predict the intent of a message
+//	history <bot-id> <chat-id> show the conversation history of a chat
+//	users <bot-id>             list the chat users of a bot
+//	whoami                     show the logged in user
+//	chat <bot-id>              chat with a bot, see package repl
+//	chat -offline ...          chat with a bot run locally
+//	plan [<dir>]               show what apply would change
+//	apply [<dir>]              create or update the bot of a manifest
+//	diff <old> <new>           compare two manifests
+//	import-rasa <dir>          print the manifest of a Rasa project
+//
+// The API key is read from the SARUFI_API_KEY environment variable
+// or from the config file, by default sarufi/config.yaml in the user
+// config directory:
+//
+//	api_key: your_api_token
+//	base_url: https://developers.sarufi.io/
+//
+// The exit code is 0 on success, 1 on failure, 2 on usage errors,
+// and 3, 4, 5 or 6 when the API answers 401, 404, 409 or 422. A bot
+// changed by someone else since it was fetched also exits with 5.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/sarufi-io/sarufi-golang-sdk"
+)
+
+// Exit codes.
+const (
+	exitOK = iota
+	exitFailure
+	exitUsage
+	exitUnauthorized
+	exitNotFound
+	exitConflict
+	exitInvalid
+)
+
+// errUsage is returned by commands called with wrong arguments.
+var errUsage = errors.New("usage")
+
+// cli holds the global flags and the streams of a run.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	configPath string
+	output     string
+	config     config
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("sarufi", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&c.configPath, "config", "", "config file (default sarufi/config.yaml in the user config directory)")
+	fs.StringVar(&c.output, "o", "table", "output format: table, json or yaml")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: sarufi [flags] <command> [arguments]")
+		fmt.Fprintln(stderr, "commands: bots, intents, flows, predict, history, users, whoami, chat, plan, apply, diff, import-rasa")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if !validFormat(c.output) {
+		fmt.Fprintf(stderr, "sarufi: unknown output format %q\n", c.output)
+		return exitUsage
+	}
+
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "sarufi: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return exitUsage
+	}
+
+	var err error
+	c.config, err = loadConfig(c.configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "sarufi: %v\n", err)
+		return exitFailure
+	}
+
+	if err := cmd(ctx, c, fs.Args()[1:]); err != nil {
+		fmt.Fprintf(stderr, "sarufi: %v\n", err)
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+// exitCode maps an error to the exit code of the process.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case sarufi.IsUnauthorized(err):
+		return exitUnauthorized
+	case sarufi.IsNotFound(err):
+		return exitNotFound
+	case sarufi.IsConflict(err), errors.Is(err, sarufi.ErrStaleBot):
+		return exitConflict
+	case sarufi.IsUnprocessableEntity(err):
+		return exitInvalid
+	}
+	return exitFailure
+}
+
+// usageError returns an error printed with the usage of a command.
+func usageError(usage string) error {
+	return fmt.Errorf("%w: sarufi %s", errUsage, usage)
+}
+
+// application returns an Application using the configured key.
+func (c *cli) application() (*sarufi.Application, error) {
+	if c.config.APIKey == "" {
+		return nil, errors.New("no API key: set SARUFI_API_KEY or api_key in the config file")
+	}
+	opts := []sarufi.Option{
+		sarufi.WithAPIKey(c.config.APIKey),
+		sarufi.WithUserAgent("sarufi-cli"),
+	}
+	if c.config.BaseURL != "" {
+		opts = append(opts, sarufi.WithBaseURL(c.config.BaseURL))
+	}
+	return sarufi.NewApplication(opts...), nil
+}
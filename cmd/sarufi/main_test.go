@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sarufi "github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/sarufitest"
+)
+
+// newServer starts a fake API with a pizza bot and returns it with
+// the bot's ID and a config file pointing at it.
+func newServer(t *testing.T) (*sarufitest.Server, int, string) {
+	t.Helper()
+	srv := sarufitest.NewServer()
+	t.Cleanup(srv.Close)
+	id := srv.AddBot(sarufi.Bot{
+		Name:    "Pizza",
+		Intents: map[string][]string{"order_pizza": {"I want pizza"}},
+		Flows: sarufi.Flows{
+			"order_pizza": {State: &sarufi.FlowState{Message: []string{"How many?"}, NextState: "end"}},
+		},
+	})
+
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	data := fmt.Sprintf("api_key: %s\nbase_url: %s\n", sarufitest.DefaultAPIKey, srv.URL)
+	if err := os.WriteFile(config, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SARUFI_API_KEY", "")
+	t.Setenv("SARUFI_BASE_URL", "")
+	return srv, id, config
+}
+
+// runCLI runs the command line tool with a config file and returns
+// its exit code and output.
+func runCLI(t *testing.T, config, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), append([]string{"-config", config}, args...), strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestBotCommandsSendOneRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		path string
+	}{
+		{"predict", []string{"predict", "%d", "I want pizza"}, "/predict/intent"},
+		{"history", []string{"history", "%d", "chat"}, "/conversation/history/%d/chat"},
+		{"users", []string{"users", "%d"}, "/chatbot/%d/users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, id, config := newServer(t)
+			bot, err := srv.Application().GetBotContext(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := bot.Session("chat").Send(context.Background(), "I want pizza"); err != nil {
+				t.Fatal(err)
+			}
+			before := len(srv.Requests())
+
+			args := append([]string(nil), tt.args...)
+			for i, arg := range args {
+				if arg == "%d" {
+					args[i] = fmt.Sprint(id)
+				}
+			}
+
+			code, stdout, stderr := runCLI(t, config, "", args...)
+			if code != exitOK {
+				t.Fatalf("exit code %d: %s", code, stderr)
+			}
+			if stdout == "" {
+				t.Error("nothing printed")
+			}
+			requests := srv.Requests()[before:]
+			if len(requests) != 1 {
+				t.Fatalf("%d requests, want 1: %v", len(requests), requests)
+			}
+			want := tt.path
+			if strings.Contains(want, "%d") {
+				want = fmt.Sprintf(want, id)
+			}
+			if requests[0].Path != want {
+				t.Errorf("request to %s, want %s", requests[0].Path, want)
+			}
+		})
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	_, id, config := newServer(t)
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no command", nil, exitUsage},
+		{"unknown command", []string{"launch"}, exitUsage},
+		{"invalid bot ID", []string{"bots", "get", "pizza"}, exitUsage},
+		{"missing bot", []string{"bots", "get", "999"}, exitNotFound},
+		{"existing bot", []string{"bots", "get", fmt.Sprint(id)}, exitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _, stderr := runCLI(t, config, "", tt.args...); code != tt.code {
+				t.Errorf("exit code %d, want %d: %s", code, tt.code, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// table is the table form of a result.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(cells ...interface{}) {
+	row := make([]string, len(cells))
+	for i, cell := range cells {
+		row[i] = fmt.Sprint(cell)
+	}
+	t.rows = append(t.rows, row)
+}
+
+func validFormat(format string) bool {
+	switch format {
+	case "table", "json", "yaml":
+		return true
+	}
+	return false
+}
+
+// print writes value in the output format. The table format uses
+// t, the others the JSON form of value.
+func (c *cli) print(value interface{}, t *table) error {
+	switch c.output {
+	case "json":
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	case "yaml":
+		return writeYAML(c.stdout, value)
+	}
+	return writeTable(c.stdout, t)
+}
+
+func writeTable(w io.Writer, t *table) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if len(t.header) > 0 {
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	}
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeYAML writes the JSON form of value as YAML, so the keys
+// and their order are the ones of the API.
+func writeYAML(w io.Writer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle drops the JSON flow style of a decoded node.
+func blockStyle(node *yaml.Node) {
+	if node.Kind != yaml.ScalarNode || node.Tag == "!!str" {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sarufi-io/sarufi-golang-sdk"
+)
+
+// stateFile is the file, next to the manifest, remembering the ID
+// of the bot created from it.
+const stateFile = ".sarufi-state.json"
+
+// manifestFiles are the manifest names looked for in a directory.
+var manifestFiles = []string{"sarufi.yaml", "sarufi.yml", "sarufi.json"}
+
+// deployState is the content of the state file.
+type deployState struct {
+	BotID int `json:"bot_id"`
+}
+
+// plan is what apply would do.
+type plan struct {
+	Action string         `json:"action"`
+	BotID  int            `json:"bot_id,omitempty"`
+	Name   string         `json:"name"`
+	Diff   sarufi.BotDiff `json:"diff"`
+
+	dir      string
+	manifest *sarufi.Manifest
+	remote   *sarufi.Bot
+	desired  *sarufi.Bot
+}
+
+// Plan actions.
+const (
+	actionCreate = "create"
+	actionUpdate = "update"
+	actionNone   = "none"
+)
+
+func planCommand(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("plan")
+	file := fs.String("f", "", "manifest file (default sarufi.yaml, sarufi.yml or sarufi.json in the directory)")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 1 {
+		return usageError("plan [-f <manifest>] [<dir>]")
+	}
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+	p, err := makePlan(ctx, app, fs.Arg(0), *file)
+	if err != nil {
+		return err
+	}
+	return c.printPlan(p)
+}
+
+func applyCommand(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("apply")
+	file := fs.String("f", "", "manifest file (default sarufi.yaml, sarufi.yml or sarufi.json in the directory)")
+	autoApprove := fs.Bool("auto-approve", false, "apply without asking for confirmation")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 1 {
+		return usageError("apply [-f <manifest>] [-auto-approve] [<dir>]")
+	}
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+	p, err := makePlan(ctx, app, fs.Arg(0), *file)
+	if err != nil {
+		return err
+	}
+	if err := c.printPlan(p); err != nil {
+		return err
+	}
+	if p.Action == actionNone {
+		return nil
+	}
+
+	if issues := p.manifest.Validate(); issues.HasErrors() {
+		for _, issue := range issues {
+			fmt.Fprintln(c.stderr, issue)
+		}
+		return errors.New("the manifest has errors, nothing was applied")
+	}
+	if p.manifest.Webhook != nil && p.manifest.Webhook.URL != "" {
+		if err := sarufi.ValidateWebhookURL(p.manifest.Webhook.URL); err != nil {
+			return err
+		}
+	}
+
+	if !*autoApprove {
+		fmt.Fprint(c.stderr, "Apply these changes? Only 'yes' will be accepted: ")
+		answer, _ := bufio.NewReader(c.stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "yes" {
+			return errors.New("apply cancelled")
+		}
+	}
+
+	remote, diff, desired := p.remote, p.Diff, p.desired
+	if p.Action == actionCreate {
+		remote, err = app.CreateBotContext(ctx, p.manifest.Name, p.manifest.Description, p.manifest.Industry, p.manifest.VisibleOnCommunity)
+		if err != nil {
+			return err
+		}
+		// Remember the bot first, so a failed update is retried on
+		// the same bot instead of creating another one.
+		if err := writeState(p.dir, deployState{BotID: remote.Id}); err != nil {
+			return fmt.Errorf("bot %d was created but its ID was not saved: %w", remote.Id, err)
+		}
+		created := *remote
+		p.manifest.ApplyTo(&created)
+		desired = &created
+		diff = sarufi.DiffBots(remote, desired)
+	}
+
+	// Send only the fields that changed, refusing to overwrite
+	// changes made since the plan fetched the bot.
+	if update := updateOf(diff, desired); !update.IsZero() {
+		update.Base = remote
+		if _, err := app.UpdateBotFields(ctx, remote.Id, update); err != nil {
+			return err
+		}
+	}
+	if c.output == "table" {
+		fmt.Fprintf(c.stdout, "Applied, bot %d is up to date.\n", remote.Id)
+	}
+	return nil
+}
+
+// updateOf returns the update setting the fields of the diff to
+// their values in bot. Intents, flows and trigger intents are
+// sent whole when any of them changed, as the API replaces them.
+func updateOf(diff sarufi.BotDiff, bot *sarufi.Bot) sarufi.BotUpdate {
+	var update sarufi.BotUpdate
+	for _, field := range diff.Fields {
+		switch field.Field {
+		case "name":
+			update.Name = &bot.Name
+		case "description":
+			update.Description = &bot.Description
+		case "industry":
+			update.Industry = &bot.Industry
+		case "visible_on_community":
+			update.VisibleOnCommunity = &bot.VisibleOnCommunity
+		case "model_name":
+			update.ModelName = &bot.ModelName
+		}
+	}
+	if w := diff.Webhook; w != nil {
+		if w.URL != nil {
+			update.WebhookURL = &bot.WebhookURL
+		}
+		if w.AddedTriggerIntents != nil || w.RemovedTriggerIntents != nil {
+			triggers := bot.WebhookTriggerIntents
+			if triggers == nil {
+				triggers = []string{}
+			}
+			update.WebhookTriggerIntents = &triggers
+		}
+	}
+	if len(diff.Intents) > 0 {
+		intents := bot.Intents
+		if intents == nil {
+			intents = map[string][]string{}
+		}
+		update.Intents = &intents
+	}
+	if len(diff.Flows) > 0 {
+		flows := bot.Flows
+		if flows == nil {
+			flows = sarufi.Flows{}
+		}
+		update.Flows = &flows
+	}
+	return update
+}
+
+func diffCommand(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 2 {
+		return usageError("diff <old-manifest> <new-manifest>")
+	}
+	old, err := sarufi.LoadManifest(args[0])
+	if err != nil {
+		return err
+	}
+	new, err := sarufi.LoadManifest(args[1])
+	if err != nil {
+		return err
+	}
+	diff := sarufi.DiffBots(old.Bot(), new.Bot())
+	if c.output != "table" {
+		return c.print(diff, nil)
+	}
+	fmt.Fprint(c.stdout, diff)
+	return nil
+}
+
+// makePlan loads the manifest and compares it with the bot the
+// state file points to. The state file is kept in dir, by default
+// the directory of the manifest given with -f, else the current
+// directory.
+func makePlan(ctx context.Context, app *sarufi.Application, dir, file string) (*plan, error) {
+	switch {
+	case dir != "":
+	case file != "":
+		dir = filepath.Dir(file)
+	default:
+		dir = "."
+	}
+	path, err := findManifest(dir, file)
+	if err != nil {
+		return nil, err
+	}
+	m, err := sarufi.LoadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &plan{Name: m.Name, dir: dir, manifest: m}
+	state, err := readState(dir)
+	if err != nil {
+		return nil, err
+	}
+	if state.BotID != 0 {
+		remote, err := app.GetBotContext(ctx, state.BotID)
+		switch {
+		case err == nil:
+			p.remote = remote
+		case sarufi.IsNotFound(err):
+			// The bot was deleted, create it again.
+		default:
+			return nil, err
+		}
+	}
+
+	if p.remote == nil {
+		p.Action = actionCreate
+		p.desired = m.Bot()
+		p.Diff = sarufi.DiffBots(nil, p.desired)
+		return p, nil
+	}
+
+	desired := *p.remote
+	m.ApplyTo(&desired)
+	p.BotID = p.remote.Id
+	p.desired = &desired
+	p.Diff = sarufi.DiffBots(p.remote, p.desired)
+	p.Action = actionUpdate
+	if p.Diff.IsEmpty() {
+		p.Action = actionNone
+	}
+	return p, nil
+}
+
+func (c *cli) printPlan(p *plan) error {
+	if c.output != "table" {
+		return c.print(p, nil)
+	}
+
+	switch p.Action {
+	case actionNone:
+		fmt.Fprintf(c.stdout, "Bot %d %q is up to date, no changes.\n", p.BotID, p.Name)
+		return nil
+	case actionCreate:
+		fmt.Fprintf(c.stdout, "Bot %q will be created:\n", p.Name)
+	default:
+		fmt.Fprintf(c.stdout, "Bot %d %q will be updated:\n", p.BotID, p.Name)
+	}
+	for _, line := range strings.SplitAfter(p.Diff.String(), "\n") {
+		if line != "" {
+			fmt.Fprint(c.stdout, "  ", line)
+		}
+	}
+	fmt.Fprintf(c.stdout, "Plan: %d changes.\n", p.Diff.Len())
+	return nil
+}
+
+// findManifest returns the path of the manifest to use.
+func findManifest(dir, file string) (string, error) {
+	if file != "" {
+		return file, nil
+	}
+	for _, name := range manifestFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no manifest in %s, expected one of %s", dir, strings.Join(manifestFiles, ", "))
+}
+
+func readState(dir string) (deployState, error) {
+	var state deployState
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("%s: %w", stateFile, err)
+	}
+	return state, nil
+}
+
+func writeState(dir string, state deployState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, stateFile), append(data, '\n'), 0o644)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const manifest = `name: Pizza bot
+description: Orders pizza
+industry: Food
+intents:
+  order_pizza: ["I want pizza"]
+flows:
+  order_pizza:
+    message: ["How many pizzas?"]
+    next_state: end
+`
+
+func writeManifest(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "sarufi.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyWithManifestFile(t *testing.T) {
+	srv, _, config := newServer(t)
+	dir := t.TempDir()
+	path := writeManifest(t, dir, manifest)
+
+	code, stdout, stderr := runCLI(t, config, "", "apply", "-f", path, "-auto-approve")
+	if code != exitOK {
+		t.Fatalf("apply: exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "will be created") {
+		t.Errorf("apply printed %q", stdout)
+	}
+	state, err := readState(dir)
+	if err != nil || state.BotID == 0 {
+		t.Fatalf("state next to the manifest: %+v, %v", state, err)
+	}
+	if _, err := os.Stat(stateFile); err == nil {
+		t.Errorf("state file written in the current directory")
+	}
+
+	code, stdout, stderr = runCLI(t, config, "", "plan", "-f", path)
+	if code != exitOK {
+		t.Fatalf("plan: exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "is up to date") {
+		t.Errorf("plan after apply printed %q", stdout)
+	}
+
+	bot, ok := srv.Bot(state.BotID)
+	if !ok || bot.Name != "Pizza bot" || len(bot.Intents["order_pizza"]) != 1 || bot.Flows["order_pizza"].State == nil {
+		t.Errorf("created bot %+v", bot)
+	}
+}
+
+func TestApplySendsChangedFields(t *testing.T) {
+	srv, _, config := newServer(t)
+	dir := t.TempDir()
+	writeManifest(t, dir, manifest)
+	if code, _, stderr := runCLI(t, config, "", "apply", "-auto-approve", dir); code != exitOK {
+		t.Fatalf("apply: exit code %d: %s", code, stderr)
+	}
+
+	tests := []struct {
+		name   string
+		change func(string) string
+		fields []string
+	}{
+		{"description", func(m string) string {
+			return strings.Replace(m, "Orders pizza", "Sells pizza", 1)
+		}, []string{"description"}},
+		{"example", func(m string) string {
+			return strings.Replace(m, `["I want pizza"]`, `["I want pizza", "pizza please"]`, 1)
+		}, []string{"intents"}},
+		{"name and next state", func(m string) string {
+			m = strings.Replace(m, "name: Pizza bot", "name: Pizzeria", 1)
+			return strings.Replace(m, "next_state: end", "next_state: order_pizza", 1)
+		}, []string{"flows", "name"}},
+	}
+	current := manifest
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current = tt.change(current)
+			writeManifest(t, dir, current)
+			before := len(srv.Requests())
+			if code, _, stderr := runCLI(t, config, "", "apply", "-auto-approve", dir); code != exitOK {
+				t.Fatalf("apply: exit code %d: %s", code, stderr)
+			}
+
+			var puts []map[string]json.RawMessage
+			for _, req := range srv.Requests()[before:] {
+				if req.Method == "PUT" {
+					var body map[string]json.RawMessage
+					if err := json.Unmarshal(req.Body, &body); err != nil {
+						t.Fatal(err)
+					}
+					puts = append(puts, body)
+				}
+			}
+			if len(puts) != 1 {
+				t.Fatalf("%d updates sent, want 1", len(puts))
+			}
+			var fields []string
+			for field := range puts[0] {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("update sent %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
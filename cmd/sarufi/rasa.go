@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sarufi-io/sarufi-golang-sdk/rasa"
+)
+
+func importRasaCommand(ctx context.Context, c *cli, args []string) error {
+	const usage = "import-rasa [-name <bot-name>] <rasa-project-dir>"
+	fs := c.flagSet("import-rasa")
+	name := fs.String("name", "Imported bot", "name of the bot in the manifest")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return usageError(usage)
+	}
+
+	result, err := rasa.ImportDir(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, problem := range result.Problems {
+		fmt.Fprintf(c.stderr, "warning: %s\n", problem)
+	}
+
+	manifest := result.Manifest(*name)
+	if c.output == "json" {
+		return c.print(manifest, nil)
+	}
+	return writeYAML(c.stdout, manifest)
+}
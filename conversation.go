@@ -0,0 +1,127 @@
+package sarufi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Reply is the answer of a bot to a single message. Flow based
+// and knowledge base bots fill it the same way: Message holds
+// every text message and Actions every action, text included,
+// so callers never need to know which kind of bot answered.
+type Reply struct {
+	ChatID    string     `json:"chat_id,omitempty"`
+	Message   []string   `json:"message"`
+	Actions   ActionList `json:"actions"`
+	Memory    Memory     `json:"memory"`
+	NextState string     `json:"next_state"`
+}
+
+// UnmarshalJSON decodes the reply of either kind of bot.
+func (r *Reply) UnmarshalJSON(data []byte) error {
+	var reply struct {
+		ChatID    string     `json:"chat_id"`
+		Message   []string   `json:"message"`
+		Actions   ActionList `json:"actions"`
+		Memory    Memory     `json:"memory"`
+		NextState string     `json:"next_state"`
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return err
+	}
+
+	if reply.ChatID != "" {
+		r.ChatID = reply.ChatID
+	}
+	r.Memory = reply.Memory
+	r.NextState = reply.NextState
+	r.Message = reply.Message
+	r.Actions = reply.Actions
+
+	switch {
+	case len(reply.Actions) > 0 && len(reply.Message) == 0:
+		for _, action := range reply.Actions {
+			if a, ok := action.(*SendMessageAction); ok {
+				r.Message = append(r.Message, a.Message...)
+			}
+		}
+	case len(reply.Actions) == 0 && len(reply.Message) > 0:
+		r.Actions = ActionList{&SendMessageAction{Message: reply.Message}}
+	}
+	return nil
+}
+
+// These helpers hold the conversation requests shared by Bot
+// and Session. They only read their arguments, so they are safe
+// to call from several goroutines.
+
+// sendMessage posts a message of a chat and decodes the reply.
+// The raw response body is returned along with it.
+func sendMessage(ctx context.Context, client *Client, botID int, chatID string, message Message, channel string) (*Reply, []byte, error) {
+	url := client.url("conversation/")
+	params, err := message.payload()
+	if err != nil {
+		return nil, nil, err
+	}
+	params["chat_id"] = chatID
+	params["bot_id"] = botID
+	params["channel"] = channel
+
+	jsonParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := client.makeRequest(ctx, "POST", url, bytes.NewBuffer(jsonParams), forBot(botID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reply := &Reply{ChatID: chatID}
+	if err := json.Unmarshal(body, reply); err != nil {
+		return nil, nil, err
+	}
+	return reply, body, nil
+}
+
+// fetchChatState asks for the current and next state of a chat.
+func fetchChatState(ctx context.Context, client *Client, botID int, chatID string) (Conversation, error) {
+	url := client.url("conversation/status")
+	params := map[string]interface{}{
+		"chat_id": chatID,
+		"bot_id":  botID,
+	}
+
+	jsonParams, err := json.Marshal(params)
+	if err != nil {
+		return Conversation{}, err
+	}
+	body, err := client.makeRequest(ctx, "POST", url, bytes.NewBuffer(jsonParams), idempotent(), forBot(botID))
+	if err != nil {
+		return Conversation{}, err
+	}
+
+	var conversation Conversation
+	if err := json.Unmarshal(body, &conversation); err != nil {
+		return Conversation{}, err
+	}
+	return conversation, nil
+}
+
+// fetchChatHistory returns the conversation history of a chat.
+func fetchChatHistory(ctx context.Context, client *Client, botID int, chatID string) ([]ConversationHistory, error) {
+	url := client.url(fmt.Sprintf("conversation/history/%d/%s", botID, chatID))
+	body, err := client.makeRequest(ctx, "GET", url, nil, forBot(botID))
+	if err != nil {
+		return nil, err
+	}
+
+	var history struct {
+		ConversationHistory []ConversationHistory `json:"conversation_history"`
+	}
+	if err := json.Unmarshal(body, &history); err != nil {
+		return nil, err
+	}
+	return history.ConversationHistory, nil
+}
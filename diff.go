@@ -0,0 +1,346 @@
+package sarufi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sarufi-io/sarufi-golang-sdk/internal/strs"
+)
+
+// ChangeKind tells whether something was added, removed or changed.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// BotDiff is the semantic difference between two bot definitions,
+// as returned by DiffBots. Runtime fields such as Conversation or
+// ChatUsers are not compared.
+type BotDiff struct {
+	Fields  []FieldChange  `json:"fields,omitempty"`
+	Webhook *WebhookChange `json:"webhook,omitempty"`
+	Intents []IntentChange `json:"intents,omitempty"`
+	Flows   []FlowChange   `json:"flows,omitempty"`
+}
+
+// FieldChange is a changed detail of the bot, e.g. its name.
+// Field is the JSON name of the field.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// WebhookChange is a change of the webhook settings.
+type WebhookChange struct {
+	URL                   *StringChange `json:"url,omitempty"`
+	AddedTriggerIntents   []string      `json:"added_trigger_intents,omitempty"`
+	RemovedTriggerIntents []string      `json:"removed_trigger_intents,omitempty"`
+}
+
+// StringChange is a changed string value.
+type StringChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// MessageChange is a changed list of messages.
+type MessageChange struct {
+	Old []string `json:"old"`
+	New []string `json:"new"`
+}
+
+// IntentChange is an added, removed or changed intent. The
+// examples of added and removed intents are all listed.
+type IntentChange struct {
+	Intent          string     `json:"intent"`
+	Kind            ChangeKind `json:"kind"`
+	AddedExamples   []string   `json:"added_examples,omitempty"`
+	RemovedExamples []string   `json:"removed_examples,omitempty"`
+}
+
+// FlowChange is an added, removed or changed flow state. Old and
+// New hold the whole node when it was added or removed, or when it
+// changed in a way the other fields do not describe, such as
+// turning from a state into a choice.
+type FlowChange struct {
+	State           string         `json:"state"`
+	Kind            ChangeKind     `json:"kind"`
+	Message         *MessageChange `json:"message,omitempty"`
+	NextState       *StringChange  `json:"next_state,omitempty"`
+	Options         []OptionChange `json:"options,omitempty"`
+	FallbackMessage *MessageChange `json:"fallback_message,omitempty"`
+	Old             *Flow          `json:"old,omitempty"`
+	New             *Flow          `json:"new,omitempty"`
+}
+
+// OptionChange is an added, removed or changed choice option.
+// Old and New are the states the reply leads to.
+type OptionChange struct {
+	Reply string     `json:"reply"`
+	Kind  ChangeKind `json:"kind"`
+	Old   string     `json:"old,omitempty"`
+	New   string     `json:"new,omitempty"`
+}
+
+// DiffBots compares the definitions of two bots: their details,
+// webhook settings, intents down to single examples, and flows
+// down to messages, next states and choice options. Changes go
+// from a to b and are sorted by name. Either bot may be nil, which
+// stands for an empty bot. The order of examples and trigger
+// intents is ignored.
+func DiffBots(a, b *Bot) BotDiff {
+	if a == nil {
+		a = &Bot{}
+	}
+	if b == nil {
+		b = &Bot{}
+	}
+
+	var d BotDiff
+	field := func(name string, old, new interface{}) {
+		if old != new {
+			d.Fields = append(d.Fields, FieldChange{Field: name, Old: old, New: new})
+		}
+	}
+	field("name", a.Name, b.Name)
+	field("description", a.Description, b.Description)
+	field("industry", a.Industry, b.Industry)
+	field("visible_on_community", a.VisibleOnCommunity, b.VisibleOnCommunity)
+	field("model_name", a.ModelName, b.ModelName)
+
+	webhook := WebhookChange{
+		AddedTriggerIntents:   missing(b.WebhookTriggerIntents, a.WebhookTriggerIntents),
+		RemovedTriggerIntents: missing(a.WebhookTriggerIntents, b.WebhookTriggerIntents),
+	}
+	if a.WebhookURL != b.WebhookURL {
+		webhook.URL = &StringChange{Old: a.WebhookURL, New: b.WebhookURL}
+	}
+	if webhook.URL != nil || webhook.AddedTriggerIntents != nil || webhook.RemovedTriggerIntents != nil {
+		d.Webhook = &webhook
+	}
+
+	for _, name := range unionKeys(a.Intents, b.Intents) {
+		old, inA := a.Intents[name]
+		new, inB := b.Intents[name]
+		change := IntentChange{
+			Intent:          name,
+			Kind:            Changed,
+			AddedExamples:   missing(new, old),
+			RemovedExamples: missing(old, new),
+		}
+		switch {
+		case !inA:
+			change.Kind = Added
+		case !inB:
+			change.Kind = Removed
+		case change.AddedExamples == nil && change.RemovedExamples == nil:
+			continue
+		}
+		d.Intents = append(d.Intents, change)
+	}
+
+	for _, name := range unionKeys(a.Flows, b.Flows) {
+		old, inA := a.Flows[name]
+		new, inB := b.Flows[name]
+		switch {
+		case !inA:
+			d.Flows = append(d.Flows, FlowChange{State: name, Kind: Added, New: &new})
+		case !inB:
+			d.Flows = append(d.Flows, FlowChange{State: name, Kind: Removed, Old: &old})
+		default:
+			if change, ok := diffFlow(name, old, new); ok {
+				d.Flows = append(d.Flows, change)
+			}
+		}
+	}
+	return d
+}
+
+// diffFlow compares two versions of a flow state.
+func diffFlow(name string, old, new Flow) (FlowChange, bool) {
+	change := FlowChange{State: name, Kind: Changed}
+	switch {
+	case old.State != nil && new.State != nil:
+		if !strs.Equal(old.State.Message, new.State.Message) {
+			change.Message = &MessageChange{Old: old.State.Message, New: new.State.Message}
+		}
+		if old.State.NextState != new.State.NextState {
+			change.NextState = &StringChange{Old: old.State.NextState, New: new.State.NextState}
+		}
+	case old.Choice != nil && new.Choice != nil:
+		for _, reply := range unionKeys(old.Choice.Options, new.Choice.Options) {
+			oldTarget, inOld := old.Choice.Options[reply]
+			newTarget, inNew := new.Choice.Options[reply]
+			switch {
+			case !inOld:
+				change.Options = append(change.Options, OptionChange{Reply: reply, Kind: Added, New: newTarget})
+			case !inNew:
+				change.Options = append(change.Options, OptionChange{Reply: reply, Kind: Removed, Old: oldTarget})
+			case oldTarget != newTarget:
+				change.Options = append(change.Options, OptionChange{Reply: reply, Kind: Changed, Old: oldTarget, New: newTarget})
+			}
+		}
+		if !strs.Equal(old.Choice.FallbackMessage, new.Choice.FallbackMessage) {
+			change.FallbackMessage = &MessageChange{Old: old.Choice.FallbackMessage, New: new.Choice.FallbackMessage}
+		}
+	}
+
+	described := change.Message != nil || change.NextState != nil || change.Options != nil || change.FallbackMessage != nil
+	if !described {
+		// A state turned into a choice, or fields this package
+		// does not know about changed.
+		oldJSON, _ := json.Marshal(old)
+		newJSON, _ := json.Marshal(new)
+		if bytes.Equal(oldJSON, newJSON) {
+			return change, false
+		}
+		change.Old, change.New = &old, &new
+	}
+	return change, true
+}
+
+// IsEmpty reports whether the bots had the same definition.
+func (d BotDiff) IsEmpty() bool {
+	return len(d.Fields) == 0 && d.Webhook == nil && len(d.Intents) == 0 && len(d.Flows) == 0
+}
+
+// Len returns the number of changed fields, webhook settings,
+// intents and flow states.
+func (d BotDiff) Len() int {
+	n := len(d.Fields) + len(d.Intents) + len(d.Flows)
+	if d.Webhook != nil {
+		n++
+	}
+	return n
+}
+
+// JSON renders the diff as indented JSON.
+func (d BotDiff) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// String renders the diff as text, one change per line, marked
+// with "+" when added, "-" when removed and "~" when changed:
+//
+//	~ description: "Orders pizza" -> "Sells pizza"
+//	~ intent greets
+//	    + "hey"
+//	+ flow goodbye
+//	~ flow order_pizza
+//	    ~ next_state: "number" -> "count"
+func (d BotDiff) String() string {
+	var b strings.Builder
+	for _, f := range d.Fields {
+		fmt.Fprintf(&b, "~ %s: %s -> %s\n", f.Field, quote(f.Old), quote(f.New))
+	}
+
+	if w := d.Webhook; w != nil {
+		if w.URL != nil {
+			fmt.Fprintf(&b, "~ webhook_url: %q -> %q\n", w.URL.Old, w.URL.New)
+		}
+		for _, intent := range w.AddedTriggerIntents {
+			fmt.Fprintf(&b, "+ webhook trigger intent %s\n", intent)
+		}
+		for _, intent := range w.RemovedTriggerIntents {
+			fmt.Fprintf(&b, "- webhook trigger intent %s\n", intent)
+		}
+	}
+
+	for _, i := range d.Intents {
+		fmt.Fprintf(&b, "%s intent %s\n", mark(i.Kind), i.Intent)
+		for _, example := range i.AddedExamples {
+			fmt.Fprintf(&b, "    + %q\n", example)
+		}
+		for _, example := range i.RemovedExamples {
+			fmt.Fprintf(&b, "    - %q\n", example)
+		}
+	}
+
+	for _, f := range d.Flows {
+		fmt.Fprintf(&b, "%s flow %s\n", mark(f.Kind), f.State)
+		if f.Kind != Changed {
+			continue
+		}
+		if f.Message != nil {
+			fmt.Fprintf(&b, "    ~ message: %s -> %s\n", quote(f.Message.Old), quote(f.Message.New))
+		}
+		if f.NextState != nil {
+			fmt.Fprintf(&b, "    ~ next_state: %q -> %q\n", f.NextState.Old, f.NextState.New)
+		}
+		for _, o := range f.Options {
+			switch o.Kind {
+			case Added:
+				fmt.Fprintf(&b, "    + option %q -> %s\n", o.Reply, o.New)
+			case Removed:
+				fmt.Fprintf(&b, "    - option %q -> %s\n", o.Reply, o.Old)
+			default:
+				fmt.Fprintf(&b, "    ~ option %q: %s -> %s\n", o.Reply, o.Old, o.New)
+			}
+		}
+		if f.FallbackMessage != nil {
+			fmt.Fprintf(&b, "    ~ fallback_message: %s -> %s\n", quote(f.FallbackMessage.Old), quote(f.FallbackMessage.New))
+		}
+		if f.Old != nil && f.New != nil {
+			fmt.Fprintf(&b, "    ~ %s -> %s\n", quote(f.Old), quote(f.New))
+		}
+	}
+	return b.String()
+}
+
+func mark(kind ChangeKind) string {
+	switch kind {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	}
+	return "~"
+}
+
+// quote writes a value as JSON, which quotes strings and lists.
+func quote(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// missing returns the values of list that are not in other,
+// sorted, or nil if there are none.
+func missing(list, other []string) []string {
+	in := make(map[string]bool, len(other))
+	for _, value := range other {
+		in[value] = true
+	}
+	var result []string
+	for _, value := range list {
+		if !in[value] {
+			result = strs.AppendUnique(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// unionKeys returns the keys of both maps, sorted.
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,148 @@
+package sarufi
+
+import "testing"
+
+// diffBase is the bot the DiffBots cases change.
+func diffBase() *Bot {
+	return &Bot{
+		Name:                  "Pizza",
+		Description:           "Orders pizza",
+		WebhookURL:            "https://example.com/a",
+		WebhookTriggerIntents: []string{"order_pizza"},
+		Intents: map[string][]string{
+			"greets":      {"hi", "hello"},
+			"order_pizza": {"I want pizza"},
+		},
+		Flows: Flows{
+			"greets": {State: &FlowState{Message: []string{"Hi"}, NextState: "end"}},
+			"size": {Choice: &ChoiceState{
+				Options:         map[string]string{"1": "small", "2": "large"},
+				FallbackMessage: []string{"Pick 1 or 2"},
+			}},
+		},
+	}
+}
+
+func TestDiffBots(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(b *Bot)
+		want   string
+		len    int
+	}{
+		{"same bot", func(b *Bot) {}, "", 0},
+		{
+			"fields",
+			func(b *Bot) { b.Description = "Sells pizza"; b.VisibleOnCommunity = true },
+			"~ description: \"Orders pizza\" -> \"Sells pizza\"\n~ visible_on_community: false -> true\n",
+			2,
+		},
+		{
+			"webhook",
+			func(b *Bot) {
+				b.WebhookURL = "https://example.com/b"
+				b.WebhookTriggerIntents = []string{"greets"}
+			},
+			"~ webhook_url: \"https://example.com/a\" -> \"https://example.com/b\"\n+ webhook trigger intent greets\n- webhook trigger intent order_pizza\n",
+			1,
+		},
+		{
+			"trigger intent repeated",
+			func(b *Bot) { b.WebhookTriggerIntents = []string{"order_pizza", "order_pizza"} },
+			"",
+			0,
+		},
+		{
+			"intent added",
+			func(b *Bot) { b.Intents["goodbye"] = []string{"bye"} },
+			"+ intent goodbye\n    + \"bye\"\n",
+			1,
+		},
+		{
+			"intent removed",
+			func(b *Bot) { delete(b.Intents, "order_pizza") },
+			"- intent order_pizza\n    - \"I want pizza\"\n",
+			1,
+		},
+		{
+			"intent examples changed",
+			func(b *Bot) { b.Intents["greets"] = []string{"hello", "hey"} },
+			"~ intent greets\n    + \"hey\"\n    - \"hi\"\n",
+			1,
+		},
+		{
+			"intent examples reordered",
+			func(b *Bot) { b.Intents["greets"] = []string{"hello", "hi"} },
+			"",
+			0,
+		},
+		{
+			"flow added",
+			func(b *Bot) { b.Flows["goodbye"] = Flow{State: &FlowState{Message: []string{"Bye"}, NextState: "end"}} },
+			"+ flow goodbye\n",
+			1,
+		},
+		{
+			"flow removed",
+			func(b *Bot) { delete(b.Flows, "greets") },
+			"- flow greets\n",
+			1,
+		},
+		{
+			"flow message and next state",
+			func(b *Bot) {
+				b.Flows["greets"] = Flow{State: &FlowState{Message: []string{"Hello"}, NextState: "size"}}
+			},
+			"~ flow greets\n    ~ message: [\"Hi\"] -> [\"Hello\"]\n    ~ next_state: \"end\" -> \"size\"\n",
+			1,
+		},
+		{
+			"choice options and fallback",
+			func(b *Bot) {
+				b.Flows["size"] = Flow{Choice: &ChoiceState{
+					Options:         map[string]string{"1": "medium", "3": "large"},
+					FallbackMessage: []string{"Pick 1 or 3"},
+				}}
+			},
+			"~ flow size\n    ~ option \"1\": small -> medium\n    - option \"2\" -> large\n    + option \"3\" -> large\n    ~ fallback_message: [\"Pick 1 or 2\"] -> [\"Pick 1 or 3\"]\n",
+			1,
+		},
+		{
+			"state turned into a choice",
+			func(b *Bot) { b.Flows["greets"] = Flow{Choice: &ChoiceState{Options: map[string]string{"1": "end"}}} },
+			"~ flow greets\n    ~ {\"message\":[\"Hi\"],\"next_state\":\"end\"} -> {\"1\":\"end\"}\n",
+			1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := diffBase()
+			tt.change(b)
+			d := DiffBots(diffBase(), b)
+			if got := d.String(); got != tt.want {
+				t.Errorf("String() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if d.Len() != tt.len || d.IsEmpty() != (tt.len == 0) {
+				t.Errorf("Len() = %d, IsEmpty() = %v, want %d changes", d.Len(), d.IsEmpty(), tt.len)
+			}
+		})
+	}
+}
+
+func TestDiffBotsNil(t *testing.T) {
+	if d := DiffBots(nil, nil); !d.IsEmpty() {
+		t.Errorf("diff of two nil bots: %s", d)
+	}
+	d := DiffBots(nil, diffBase())
+	if len(d.Intents) != 2 || len(d.Flows) != 2 || d.Webhook == nil {
+		t.Fatalf("diff from nil: %s", d)
+	}
+	for _, f := range d.Flows {
+		if f.Kind != Added || f.New == nil {
+			t.Errorf("flow change %+v", f)
+		}
+	}
+	if d := DiffBots(diffBase(), nil); d.Intents[0].Kind != Removed {
+		t.Errorf("diff to nil: %s", d)
+	}
+}
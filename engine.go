@@ -0,0 +1,350 @@
+package sarufi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Engine runs the intents and flows of a bot locally, without the
+// API, the way Sarufi does: a message matching an intent enters
+// the flow of the same name, the reply to a state is stored in the
+// chat memory under that state's name, choices pick the state
+// mapped to the reply or send their fallback message, and the
+// "end" state finishes the conversation.
+//
+// Each chat ID has its own state and memory, kept in the Engine.
+// An Engine is safe for concurrent use: messages of one chat are
+// handled one at a time, messages of different chats in parallel.
+type Engine struct {
+	intents       map[string][]string
+	flows         Flows
+	fallback      []string
+	predictor     IntentPredictor
+	minConfidence float64
+	// trained tells whether predictor is the default classifier,
+	// which SetBot retrains.
+	trained      bool
+	onTransition func(Transition)
+
+	mu    sync.Mutex
+	chats map[string]*chatState
+}
+
+// EngineOption configures an Engine. See NewEngine.
+type EngineOption func(*Engine)
+
+// WithFallbackMessage sets the messages sent when no intent
+// matches a message.
+func WithFallbackMessage(messages ...string) EngineOption {
+	return func(e *Engine) {
+		e.fallback = messages
+	}
+}
+
+// WithPredictor sets the predictor used to pick the intent of a
+// message. By default a LocalClassifier trained on the bot's
+// intents is used. The predictor gets the context passed to
+// RespondContext and runs without blocking other chats, so a Bot
+// predicting through the API can be used.
+func WithPredictor(predictor IntentPredictor) EngineOption {
+	return func(e *Engine) {
+		e.predictor = predictor
+	}
+}
+
+// WithMinConfidence sets the confidence below which a prediction
+// is ignored and the fallback message is sent. It defaults to 0.2.
+func WithMinConfidence(confidence float64) EngineOption {
+	return func(e *Engine) {
+		e.minConfidence = confidence
+	}
+}
+
+// Transition is a state entered by a chat while the Engine handled
+// a message. See WithTransitionHook.
+type Transition struct {
+	ChatID string
+	// Intent is the intent whose flow the chat is in.
+	Intent    string
+	State     string
+	NextState string
+	// Message is the message that led to the state.
+	Message string
+	// Memory is a copy of the chat memory after the message.
+	Memory map[string]interface{}
+}
+
+// WithTransitionHook sets a function called with every state a
+// chat enters, e.g. to see when a webhook trigger intent fires.
+// It is called after Respond has updated the chat, from the
+// goroutine calling Respond.
+func WithTransitionHook(fn func(Transition)) EngineOption {
+	return func(e *Engine) {
+		e.onTransition = fn
+	}
+}
+
+// chatState is the progress of a single chat. turn is held while
+// a message of the chat is handled, the other fields are guarded
+// by Engine.mu.
+type chatState struct {
+	turn sync.Mutex
+
+	intent       string
+	currentState string
+	nextState    string
+	memory       map[string]interface{}
+	entered      bool
+}
+
+// NewEngine returns an Engine running the intents and flows the
+// bot has now. Later changes to the bot are not seen until it is
+// passed to SetBot.
+func NewEngine(bot *Bot, opts ...EngineOption) *Engine {
+	e := &Engine{
+		fallback:      []string{"Sorry, I didn't understand that."},
+		minConfidence: 0.2,
+		chats:         make(map[string]*chatState),
+	}
+	e.intents, e.flows = copyDefinition(bot)
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.predictor == nil {
+		e.predictor = NewLocalClassifier(e.intents)
+		e.trained = true
+	}
+	return e
+}
+
+// SetBot replaces the intents and flows the Engine runs with those
+// the bot has now, keeping the state and memory of every chat. The
+// default classifier is retrained, a predictor set with
+// WithPredictor is kept. Chats waiting for a reply to a state the
+// bot no longer has start over with their next message.
+func (e *Engine) SetBot(bot *Bot) {
+	intents, flows := copyDefinition(bot)
+	var predictor IntentPredictor
+	if e.trained {
+		predictor = NewLocalClassifier(intents)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.intents, e.flows = intents, flows
+	if predictor != nil {
+		e.predictor = predictor
+	}
+	for _, chat := range e.chats {
+		if _, ok := e.flows[chat.nextState]; !ok {
+			chat.nextState = EndState
+		}
+	}
+}
+
+// copyDefinition copies the intents and flows of a bot.
+func copyDefinition(bot *Bot) (map[string][]string, Flows) {
+	intents := make(map[string][]string, len(bot.Intents))
+	for name, examples := range bot.Intents {
+		intents[name] = append([]string(nil), examples...)
+	}
+	flows := make(Flows, len(bot.Flows))
+	for name, flow := range bot.Flows {
+		flows[name] = flow
+	}
+	return intents, flows
+}
+
+// Respond handles a message of the given chat and returns the
+// reply in the same shape Bot.Respond fills Bot.Conversation.
+func (e *Engine) Respond(chatID, message string) (Conversation, error) {
+	return e.RespondContext(context.Background(), chatID, message)
+}
+
+// RespondContext is Respond with a context, passed to the
+// predictor when the message starts a flow.
+func (e *Engine) RespondContext(ctx context.Context, chatID, message string) (Conversation, error) {
+	conversation, transition, err := e.respond(ctx, chatID, message)
+	if err != nil {
+		return Conversation{}, err
+	}
+	if transition != nil && e.onTransition != nil {
+		e.onTransition(*transition)
+	}
+	return conversation, nil
+}
+
+// respond handles a message and returns the transition it made,
+// if any, so the hook can be called without holding the lock.
+// The intent is predicted without holding the lock either, as
+// the predictor may call the API.
+func (e *Engine) respond(ctx context.Context, chatID, message string) (Conversation, *Transition, error) {
+	e.mu.Lock()
+	chat, ok := e.chats[chatID]
+	if !ok {
+		chat = &chatState{nextState: EndState, memory: make(map[string]interface{})}
+		e.chats[chatID] = chat
+	}
+	e.mu.Unlock()
+
+	chat.turn.Lock()
+	defer chat.turn.Unlock()
+
+	// SetBot may end the flow of the chat while the intent is
+	// predicted, so whether the message starts a flow is checked
+	// again once the lock is held, and the intent predicted if it
+	// now does.
+	var prediction Prediction
+	predicted := false
+	e.mu.Lock()
+	for {
+		starting := chat.nextState == "" || chat.nextState == EndState
+		if !starting || predicted {
+			break
+		}
+		predictor := e.predictor
+		e.mu.Unlock()
+		var err error
+		if prediction, err = predictor.PredictIntent(ctx, message); err != nil {
+			return Conversation{}, nil, err
+		}
+		predicted = true
+		e.mu.Lock()
+	}
+	defer e.mu.Unlock()
+
+	chat.entered = false
+	var messages []string
+	var err error
+	if predicted {
+		messages, err = e.startFlow(chat, prediction)
+	} else {
+		messages, err = e.continueFlow(chat, message)
+	}
+	if err != nil {
+		return Conversation{}, nil, err
+	}
+
+	conversation := Conversation{
+		Message:      messages,
+		Memory:       copyMemory(chat.memory),
+		CurrentState: chat.currentState,
+		NextState:    chat.nextState,
+	}
+	if !chat.entered {
+		return conversation, nil, nil
+	}
+	return conversation, &Transition{
+		ChatID:    chatID,
+		Intent:    chat.intent,
+		State:     chat.currentState,
+		NextState: chat.nextState,
+		Message:   message,
+		Memory:    copyMemory(chat.memory),
+	}, nil
+}
+
+// State returns the current and next state of the chat.
+// Both are empty for chats the Engine has not seen.
+func (e *Engine) State(chatID string) (current, next string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if chat, ok := e.chats[chatID]; ok {
+		return chat.currentState, chat.nextState
+	}
+	return "", ""
+}
+
+// Memory returns a copy of the chat memory.
+func (e *Engine) Memory(chatID string) map[string]interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if chat, ok := e.chats[chatID]; ok {
+		return copyMemory(chat.memory)
+	}
+	return map[string]interface{}{}
+}
+
+// Reset forgets the state and memory of the chat.
+func (e *Engine) Reset(chatID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.chats, chatID)
+}
+
+// startFlow enters the flow of the predicted intent.
+func (e *Engine) startFlow(chat *chatState, prediction Prediction) ([]string, error) {
+	intent := prediction.Intent
+	if prediction.Confidence < e.minConfidence {
+		intent = ""
+	}
+	if _, ok := e.flows[intent]; intent == "" || !ok {
+		chat.intent = ""
+		chat.currentState = ""
+		chat.nextState = EndState
+		return append([]string(nil), e.fallback...), nil
+	}
+	chat.intent = intent
+	return e.enter(chat, intent)
+}
+
+// continueFlow handles the reply to the chat's next state.
+func (e *Engine) continueFlow(chat *chatState, message string) ([]string, error) {
+	name := chat.nextState
+	flow, ok := e.flows[name]
+	if !ok {
+		return nil, fmt.Errorf("state %q does not exist", name)
+	}
+
+	if flow.Choice != nil {
+		target, ok := flow.Choice.Options[strings.TrimSpace(message)]
+		if !ok {
+			chat.currentState = name
+			return append([]string(nil), flow.Choice.FallbackMessage...), nil
+		}
+		chat.memory[name] = message
+		return e.enter(chat, target)
+	}
+
+	chat.memory[name] = message
+	return e.enter(chat, name)
+}
+
+// enter moves the chat into a state and returns its messages.
+// Choice states send nothing and wait for the reply.
+func (e *Engine) enter(chat *chatState, name string) ([]string, error) {
+	chat.currentState = name
+	chat.entered = true
+	if name == EndState {
+		chat.nextState = EndState
+		return []string{}, nil
+	}
+
+	flow, ok := e.flows[name]
+	if !ok {
+		return nil, fmt.Errorf("state %q does not exist", name)
+	}
+	if flow.Choice != nil {
+		chat.nextState = name
+		return []string{}, nil
+	}
+	if flow.State == nil {
+		return nil, fmt.Errorf("state %q is neither a flow state nor a choice", name)
+	}
+
+	chat.nextState = flow.State.NextState
+	if chat.nextState == "" {
+		chat.nextState = EndState
+	}
+	return append([]string{}, flow.State.Message...), nil
+}
+
+func copyMemory(memory map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(memory))
+	for k, v := range memory {
+		result[k] = v
+	}
+	return result
+}
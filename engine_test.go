@@ -0,0 +1,220 @@
+package sarufi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func pizzaBot(t *testing.T) *Bot {
+	t.Helper()
+	bot := &Bot{Intents: pizzaIntents}
+	if err := json.Unmarshal([]byte(pizzaFlows), &bot.Flows); err != nil {
+		t.Fatal(err)
+	}
+	return bot
+}
+
+func TestEnginePizzaConversation(t *testing.T) {
+	engine := NewEngine(pizzaBot(t))
+
+	turns := []struct {
+		message string
+		reply   []string
+		current string
+		next    string
+	}{
+		{"I want pizza", []string{"Sure, How many pizzas would you like to order?"}, "order_pizza", "number_of_pizzas"},
+		{"2", []string{"Sure, What would you like to have on your pizza?", "1. Cheese", "2. Pepperoni", "3. Both"}, "number_of_pizzas", "choice_pizza_toppings"},
+		{"7", []string{"Sorry, the topping you chose is not available."}, "choice_pizza_toppings", "choice_pizza_toppings"},
+		{"1", []string{"Cool, Whats your address ?"}, "pizza_toppings", "address"},
+		{"Sinza", []string{"Sure, What is your phone number ?"}, "address", "phone_number"},
+		{"0712345678", []string{"Your order has been placed.", "Thank you for ordering with us."}, "phone_number", "end"},
+		{"bye", []string{"Bye", "See you soon"}, "goodbye", "end"},
+	}
+	for _, turn := range turns {
+		conversation, err := engine.Respond("chat", turn.message)
+		if err != nil {
+			t.Fatalf("%q: %v", turn.message, err)
+		}
+		if !reflect.DeepEqual(conversation.Message, turn.reply) {
+			t.Errorf("%q: reply %q, want %q", turn.message, conversation.Message, turn.reply)
+		}
+		if conversation.CurrentState != turn.current || conversation.NextState != turn.next {
+			t.Errorf("%q: states %s -> %s, want %s -> %s", turn.message,
+				conversation.CurrentState, conversation.NextState, turn.current, turn.next)
+		}
+	}
+
+	want := map[string]interface{}{
+		"number_of_pizzas":      "2",
+		"choice_pizza_toppings": "1",
+		"address":               "Sinza",
+		"phone_number":          "0712345678",
+	}
+	if got := engine.Memory("chat"); !reflect.DeepEqual(got, want) {
+		t.Errorf("memory %v, want %v", got, want)
+	}
+}
+
+func TestEngineChats(t *testing.T) {
+	engine := NewEngine(pizzaBot(t), WithFallbackMessage("Pardon?"))
+
+	conversation, err := engine.Respond("a", "qwzx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Pardon?"}; !reflect.DeepEqual(conversation.Message, want) || conversation.NextState != EndState {
+		t.Errorf("unknown message: %q -> %s, want %q -> end", conversation.Message, conversation.NextState, want)
+	}
+
+	if _, err := engine.Respond("a", "I want pizza"); err != nil {
+		t.Fatal(err)
+	}
+	if current, next := engine.State("a"); current != "order_pizza" || next != "number_of_pizzas" {
+		t.Errorf("chat a is at %s -> %s", current, next)
+	}
+	if current, next := engine.State("b"); current != "" || next != "" {
+		t.Errorf("unseen chat b is at %s -> %s", current, next)
+	}
+
+	engine.Reset("a")
+	if current, _ := engine.State("a"); current != "" {
+		t.Errorf("chat a is at %s after Reset", current)
+	}
+}
+
+func TestEngineTransitionHook(t *testing.T) {
+	var transitions []Transition
+	engine := NewEngine(pizzaBot(t), WithTransitionHook(func(tr Transition) {
+		transitions = append(transitions, tr)
+	}))
+
+	for _, message := range []string{"I want pizza", "2", "7", "3"} {
+		if _, err := engine.Respond("chat", message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The fallback of the choice ("7") enters no state.
+	want := []string{"order_pizza", "number_of_pizzas", "pizza_toppings"}
+	var got []string
+	for _, tr := range transitions {
+		if tr.Intent != "order_pizza" || tr.ChatID != "chat" {
+			t.Errorf("transition %+v", tr)
+		}
+		got = append(got, tr.State)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entered %v, want %v", got, want)
+	}
+}
+
+// blockingPredictor blocks on messages equal to block until its
+// context is done, and predicts order_pizza otherwise.
+type blockingPredictor struct {
+	block   string
+	started chan struct{}
+}
+
+func (p *blockingPredictor) PredictIntent(ctx context.Context, message string) (Prediction, error) {
+	if message == p.block {
+		close(p.started)
+		<-ctx.Done()
+		return Prediction{}, ctx.Err()
+	}
+	return Prediction{Intent: "order_pizza", Confidence: 1, Status: true}, nil
+}
+
+func TestEngineRespondContext(t *testing.T) {
+	predictor := &blockingPredictor{block: "slow", started: make(chan struct{})}
+	engine := NewEngine(pizzaBot(t), WithPredictor(predictor))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := engine.RespondContext(ctx, "slow-chat", "slow")
+		done <- err
+	}()
+	<-predictor.started
+
+	// A prediction in progress does not hold up other chats.
+	replied := make(chan error, 1)
+	go func() {
+		_, err := engine.Respond("other-chat", "pizza")
+		replied <- err
+	}()
+	select {
+	case err := <-replied:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("other chat blocked by a prediction in progress")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if current, next := engine.State("slow-chat"); current != "" || next != EndState {
+		t.Errorf("failed message moved the chat to %s -> %s", current, next)
+	}
+}
+
+func TestEngineSetBotWhileResponding(t *testing.T) {
+	full := pizzaBot(t)
+	// Without the first two states, chats waiting in
+	// number_of_pizzas start over.
+	reduced := pizzaBot(t)
+	delete(reduced.Flows, "order_pizza")
+	delete(reduced.Flows, "number_of_pizzas")
+	engine := NewEngine(full)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if i%2 == 0 {
+				engine.SetBot(reduced)
+			} else {
+				engine.SetBot(full)
+			}
+		}
+	}()
+
+	errs := make(chan error, 4)
+	for c := 0; c < cap(errs); c++ {
+		chatID := fmt.Sprint("chat-", c)
+		go func() {
+			for i := 0; i < 1000; i++ {
+				for _, message := range []string{"I want pizza", "2"} {
+					if _, err := engine.Respond(chatID, message); err != nil {
+						errs <- fmt.Errorf("%q: %w", message, err)
+						return
+					}
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for c := 0; c < cap(errs); c++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
@@ -1,21 +1,158 @@
 package sarufi
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError is returned by every method when the API answers with
+// a non 2xx status code. Use errors.As to inspect it, or one of
+// the Is* helpers such as IsNotFound.
+//
+// Depending on the status code, Err holds one of *Unauthorized,
+// *NotFoundError, *ConflictError or *UnprocessableEntity, so
+// errors.As(err, &sarufi.NotFoundError{}) works as well.
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Body       []byte
+	Details    []Detail
+	RequestID  string
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("Error %s", e.Err.Error())
+	}
+	if len(e.Details) > 0 && e.Details[0].Message != "" {
+		return fmt.Sprintf("Error status code %d: %s", e.StatusCode, e.Details[0].Message)
+	}
+	return fmt.Sprintf("Error status code %d: %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Unwrap returns the typed error of the response, if any.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// As allows errors.As to match the value types of the wrapped
+// errors, e.g. errors.As(err, &sarufi.NotFoundError{}).
+func (e *APIError) As(target interface{}) bool {
+	switch t := target.(type) {
+	case *Unauthorized:
+		if err, ok := e.Err.(*Unauthorized); ok {
+			*t = *err
+			return true
+		}
+	case *NotFoundError:
+		if err, ok := e.Err.(*NotFoundError); ok {
+			*t = *err
+			return true
+		}
+	case *ConflictError:
+		if err, ok := e.Err.(*ConflictError); ok {
+			*t = *err
+			return true
+		}
+	case *UnprocessableEntity:
+		if err, ok := e.Err.(*UnprocessableEntity); ok {
+			*t = *err
+			return true
+		}
+	}
+	return false
+}
+
+// newAPIError builds an APIError from a failed response.
+// It never fails, a body that cannot be parsed is kept raw.
+func newAPIError(method, url string, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     method,
+		URL:        url,
+		Body:       body,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		var unauthorized Unauthorized
+		if json.Unmarshal(body, &unauthorized) == nil {
+			apiErr.Err = &unauthorized
+			apiErr.Details = unauthorized.details()
+		}
+	case http.StatusNotFound:
+		var notFound NotFoundError
+		if json.Unmarshal(body, &notFound) == nil {
+			apiErr.Err = &notFound
+			apiErr.Details = notFound.details()
+		}
+	case http.StatusConflict:
+		var conflict ConflictError
+		if json.Unmarshal(body, &conflict) == nil {
+			apiErr.Err = &conflict
+			apiErr.Details = []Detail{conflict.Detail}
+		}
+	case http.StatusUnprocessableEntity:
+		var unprocessableEntity UnprocessableEntity
+		if json.Unmarshal(body, &unprocessableEntity) == nil {
+			apiErr.Err = &unprocessableEntity
+			apiErr.Details = unprocessableEntity.Detail
+		}
+	}
+	return apiErr
+}
+
+// StatusCode returns the HTTP status code of an APIError
+// in err's chain, or 0 if there is none.
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsUnauthorized reports whether err is a 401 response.
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
+
+// IsNotFound reports whether err is a 404 response.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict reports whether err is a 409 response.
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsUnprocessableEntity reports whether err is a 422 response.
+func IsUnprocessableEntity(err error) bool {
+	return StatusCode(err) == http.StatusUnprocessableEntity
+}
 
 type NotFoundError struct {
 	Message string `json:"message"`
 	Detail  string `json:"detail"`
 }
 
-func (nf *NotFoundError) Error() string {
-	if nf != nil {
-		if nf.Message != "" {
-			return fmt.Sprintf("status code 404: %s", nf.Message)
-		} else if nf.Detail != "" {
-			return fmt.Sprintf("status code 404: %s", nf.Detail)
-		}
+func (nf NotFoundError) Error() string {
+	if nf.Message != "" {
+		return fmt.Sprintf("status code 404: %s", nf.Message)
+	} else if nf.Detail != "" {
+		return fmt.Sprintf("status code 404: %s", nf.Detail)
 	}
-	return ""
+	return "status code 404: Not Found"
+}
+
+func (nf NotFoundError) details() []Detail {
+	return messageDetails(nf.Message, nf.Detail)
 }
 
 type Unauthorized struct {
@@ -23,15 +160,17 @@ type Unauthorized struct {
 	Detail  string `json:"detail"`
 }
 
-func (ua *Unauthorized) Error() string {
-	if ua != nil {
-		if ua.Message != "" {
-			return fmt.Sprintf("status code 401: %s", ua.Message)
-		} else if ua.Detail != "" {
-			return fmt.Sprintf("status code 401: %s", ua.Detail)
-		}
+func (ua Unauthorized) Error() string {
+	if ua.Message != "" {
+		return fmt.Sprintf("status code 401: %s", ua.Message)
+	} else if ua.Detail != "" {
+		return fmt.Sprintf("status code 401: %s", ua.Detail)
 	}
-	return ""
+	return "status code 401: Unauthorized"
+}
+
+func (ua Unauthorized) details() []Detail {
+	return messageDetails(ua.Message, ua.Detail)
 }
 
 type Detail struct {
@@ -44,20 +183,29 @@ type ConflictError struct {
 	Detail Detail `json:"detail"`
 }
 
-func (c *ConflictError) Error() string {
-	if c != nil {
-		return fmt.Sprintf("status code 409: %s", c.Detail.Message)
-	}
-	return ""
+func (c ConflictError) Error() string {
+	return fmt.Sprintf("status code 409: %s", c.Detail.Message)
 }
 
 type UnprocessableEntity struct {
 	Detail []Detail `json:"detail"`
 }
 
-func (ue *UnprocessableEntity) Error() string {
-	if ue != nil {
-		return fmt.Sprintf("status code 422: %s", ue.Detail[0].Message)
+func (ue UnprocessableEntity) Error() string {
+	if len(ue.Detail) == 0 {
+		return "status code 422: Unprocessable Entity"
+	}
+	return fmt.Sprintf("status code 422: %s", ue.Detail[0].Message)
+}
+
+// messageDetails turns the message and detail strings of
+// 401 and 404 responses into a Detail list.
+func messageDetails(message, detail string) []Detail {
+	if message == "" {
+		message = detail
+	}
+	if message == "" {
+		return nil
 	}
-	return ""
+	return []Detail{{Message: message}}
 }
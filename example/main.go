@@ -8,10 +8,8 @@ import (
 )
 
 func main() {
-	var app sarufi.Application
-
-	// Set Token
-	app.SetToken("your_api_token")
+	// Create an application with its own client and API key
+	app := sarufi.NewApplication(sarufi.WithAPIKey("your_api_token"))
 
 	// Create a new bot
 	example_bot, err := app.CreateBot("Name of your bot", "Description", "Industry", false)
@@ -38,46 +36,24 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// create a string in JSON format
-	newFlow := `
-	{
-	     "greets": {"message": ["Hi, How can I help you?"], "next_state": "end"},
-	     "order_pizza": {
-	     "message": ["Sure, How many pizzas would you like to order?"],
-	     "next_state": "number_of_pizzas"
-	 },
-	     "number_of_pizzas": {
-	     "message": [
-	         "Sure, What would you like to have on your pizza?",
-	         "1. Cheese",
-	         "2. Pepperoni",
-	         "3. Both"
-	     ],
-	     "next_state": "choice_pizza_toppings"
-	 },
-	     "choice_pizza_toppings": {
-	     "1": "pizza_toppings",
-	     "2": "pizza_toppings",
-	     "3": "pizza_toppings",
-	     "fallback_message": ["Sorry, the topping you chose is not available."]
-	 },
-	     "pizza_toppings": {
-	     "message": ["Cool, Whats your address ?"],
-	     "next_state": "address"
-	 },
-	     "address": {
-	     "message": ["Sure, What is your phone number ?"],
-	     "next_state": "phone_number"
-	 },
-	     "phone_number": {
-	     "message": ["Your order has been placed.", "Thank you for ordering with us."],
-	     "next_state": "end"
-	 },
-	     "goodbye": {"message": ["Bye", "See you soon"], "next_state": "end"}
-	}`
+	// Build the flows in Go code
+	err = sarufi.NewFlow().
+		State("greets").Say("Hi, How can I help you?").End().
+		State("order_pizza").Say("Sure, How many pizzas would you like to order?").Next("number_of_pizzas").
+		State("number_of_pizzas").
+		Say("Sure, What would you like to have on your pizza?", "1. Cheese", "2. Pepperoni", "3. Both").
+		Next("choice_pizza_toppings").
+		Choice("choice_pizza_toppings").
+		Option("1", "pizza_toppings").Option("2", "pizza_toppings").Option("3", "pizza_toppings").
+		Fallback("Sorry, the topping you chose is not available.").
+		State("pizza_toppings").Say("Cool, Whats your address ?").Next("address").
+		State("address").Say("Sure, What is your phone number ?").Next("phone_number").
+		State("phone_number").Say("Your order has been placed.", "Thank you for ordering with us.").End().
+		State("goodbye").Say("Bye", "See you soon").End().
+		Apply(example_bot)
 
 	// I am ignoring errors but you should handle them
-	if err := example_bot.CreateFlows(newFlow); err != nil {
+	if err != nil {
 		fmt.Println(err)
 	}
 
@@ -0,0 +1,220 @@
+package sarufi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EndState is the special state that ends a conversation.
+const EndState = "end"
+
+// FlowBuilder builds Bot.Flows and the matching intents in Go
+// code instead of JSON. Calls are chained, for example:
+//
+//	flows, intents, err := sarufi.NewFlow().
+//		State("order_pizza").Intent("I want pizza").Say("How many?").Next("number_of_pizzas").
+//		State("number_of_pizzas").Say("1. Cheese", "2. Pepperoni").Next("choice_pizza_toppings").
+//		Choice("choice_pizza_toppings").Option("1", "pizza_toppings").Option("2", "pizza_toppings").
+//		Fallback("Sorry, the topping you chose is not available.").
+//		State("pizza_toppings").Say("Your order has been placed.").End().
+//		Build()
+//
+// Mistakes such as duplicate states or targets that do not
+// exist are collected and returned by Build.
+type FlowBuilder struct {
+	flows   Flows
+	intents map[string][]string
+	order   []string
+	current string
+	errs    []string
+}
+
+// FlowBuildError lists every problem found while building flows.
+type FlowBuildError struct {
+	Problems []string
+}
+
+func (e *FlowBuildError) Error() string {
+	return fmt.Sprintf("invalid flow: %s", strings.Join(e.Problems, "; "))
+}
+
+// NewFlow returns an empty FlowBuilder.
+func NewFlow() *FlowBuilder {
+	return &FlowBuilder{
+		flows:   make(Flows),
+		intents: make(map[string][]string),
+	}
+}
+
+// State starts a new state that sends messages and moves on.
+func (b *FlowBuilder) State(name string) *FlowBuilder {
+	if b.start(name) {
+		b.flows[name] = Flow{State: &FlowState{}}
+	}
+	return b
+}
+
+// Choice starts a new choice state.
+func (b *FlowBuilder) Choice(name string) *FlowBuilder {
+	if b.start(name) {
+		b.flows[name] = Flow{Choice: &ChoiceState{Options: make(map[string]string)}}
+	}
+	return b
+}
+
+// Say adds messages to the current state.
+func (b *FlowBuilder) Say(messages ...string) *FlowBuilder {
+	if state := b.state("Say"); state != nil {
+		state.Message = append(state.Message, messages...)
+	}
+	return b
+}
+
+// Next sets the state the current state moves to.
+func (b *FlowBuilder) Next(state string) *FlowBuilder {
+	if current := b.state("Next"); current != nil {
+		current.NextState = state
+	}
+	return b
+}
+
+// End makes the current state end the conversation.
+func (b *FlowBuilder) End() *FlowBuilder {
+	return b.Next(EndState)
+}
+
+// Option maps a user reply of the current choice state to a state.
+func (b *FlowBuilder) Option(reply, state string) *FlowBuilder {
+	if choice := b.choice("Option"); choice != nil {
+		if _, ok := choice.Options[reply]; ok {
+			b.fail("choice %q has option %q twice", b.current, reply)
+		}
+		choice.Options[reply] = state
+	}
+	return b
+}
+
+// Fallback sets the messages sent when a reply matches no option.
+func (b *FlowBuilder) Fallback(messages ...string) *FlowBuilder {
+	if choice := b.choice("Fallback"); choice != nil {
+		choice.FallbackMessage = append(choice.FallbackMessage, messages...)
+	}
+	return b
+}
+
+// Intent adds example messages to the intent that starts the
+// current state. In Sarufi an intent and its flow share a name.
+func (b *FlowBuilder) Intent(examples ...string) *FlowBuilder {
+	if b.current == "" {
+		b.fail("Intent called before State")
+		return b
+	}
+	b.intents[b.current] = append(b.intents[b.current], examples...)
+	return b
+}
+
+// Build checks the flows and returns a copy of them with their
+// intents. The builder can be used again afterwards.
+func (b *FlowBuilder) Build() (Flows, map[string][]string, error) {
+	problems := append([]string(nil), b.errs...)
+	for _, name := range b.order {
+		flow := b.flows[name]
+		if flow.State != nil && flow.State.NextState == "" {
+			problems = append(problems, fmt.Sprintf("state %q has no next state", name))
+		}
+		if flow.Choice != nil && len(flow.Choice.Options) == 0 {
+			problems = append(problems, fmt.Sprintf("choice %q has no options", name))
+		}
+		for _, target := range flow.Targets() {
+			if _, ok := b.flows[target]; !ok && target != EndState {
+				problems = append(problems, fmt.Sprintf("state %q moves to unknown state %q", name, target))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, nil, &FlowBuildError{Problems: problems}
+	}
+
+	// Copies, so that the builder and the result can be changed
+	// independently.
+	flows := make(Flows, len(b.flows))
+	for name, flow := range b.flows {
+		flows[name] = flow.clone()
+	}
+	intents := make(map[string][]string, len(b.intents))
+	for name, examples := range b.intents {
+		intents[name] = append([]string(nil), examples...)
+	}
+	return flows, intents, nil
+}
+
+// Apply builds the flows and adds them and their intents to the
+// bot, replacing flows and intents with the same names. For
+// changes to take effect, call UpdateBot afterwards.
+func (b *FlowBuilder) Apply(bot *Bot) error {
+	if bot.Id == 0 {
+		return fmt.Errorf("No bot exists")
+	}
+	flows, intents, err := b.Build()
+	if err != nil {
+		return err
+	}
+	if bot.Flows == nil {
+		bot.Flows = make(Flows)
+	}
+	if bot.Intents == nil {
+		bot.Intents = make(map[string][]string)
+	}
+	for name, flow := range flows {
+		bot.Flows[name] = flow
+	}
+	for name, examples := range intents {
+		bot.Intents[name] = examples
+	}
+	return nil
+}
+
+// start makes name the current state, reporting duplicates.
+func (b *FlowBuilder) start(name string) bool {
+	b.current = name
+	if name == "" {
+		b.fail("state name is empty")
+		return false
+	}
+	if name == EndState {
+		b.fail("state name %q is reserved", EndState)
+		return false
+	}
+	if _, ok := b.flows[name]; ok {
+		b.fail("state %q is defined twice", name)
+		return false
+	}
+	b.order = append(b.order, name)
+	return true
+}
+
+// state returns the current message state, or reports that
+// the named call needs one.
+func (b *FlowBuilder) state(call string) *FlowState {
+	flow, ok := b.flows[b.current]
+	if !ok || flow.State == nil {
+		b.fail("%s called outside of a State", call)
+		return nil
+	}
+	return flow.State
+}
+
+// choice returns the current choice state, or reports that
+// the named call needs one.
+func (b *FlowBuilder) choice(call string) *ChoiceState {
+	flow, ok := b.flows[b.current]
+	if !ok || flow.Choice == nil {
+		b.fail("%s called outside of a Choice", call)
+		return nil
+	}
+	return flow.Choice
+}
+
+func (b *FlowBuilder) fail(format string, args ...interface{}) {
+	b.errs = append(b.errs, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,160 @@
+package sarufi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFlowBuilderBuild(t *testing.T) {
+	b := NewFlow().
+		State("order_pizza").Intent("I want pizza", "pizza please").Say("How many pizzas?").Next("number_of_pizzas").
+		State("number_of_pizzas").Say("1. Cheese", "2. Pepperoni").Next("choice_pizza_toppings").
+		Choice("choice_pizza_toppings").Option("1", "pizza_toppings").Option("2", "pizza_toppings").
+		Fallback("Sorry, the topping you chose is not available.").
+		State("pizza_toppings").Say("Your order has been placed.").End()
+
+	flows, intents, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFlows := Flows{
+		"order_pizza":      {State: &FlowState{Message: []string{"How many pizzas?"}, NextState: "number_of_pizzas"}},
+		"number_of_pizzas": {State: &FlowState{Message: []string{"1. Cheese", "2. Pepperoni"}, NextState: "choice_pizza_toppings"}},
+		"choice_pizza_toppings": {Choice: &ChoiceState{
+			Options:         map[string]string{"1": "pizza_toppings", "2": "pizza_toppings"},
+			FallbackMessage: []string{"Sorry, the topping you chose is not available."},
+		}},
+		"pizza_toppings": {State: &FlowState{Message: []string{"Your order has been placed."}, NextState: EndState}},
+	}
+	if !reflect.DeepEqual(flows, wantFlows) {
+		got, _ := flows.MarshalJSON()
+		want, _ := wantFlows.MarshalJSON()
+		t.Errorf("flows %s, want %s", got, want)
+	}
+	if want := map[string][]string{"order_pizza": {"I want pizza", "pizza please"}}; !reflect.DeepEqual(intents, want) {
+		t.Errorf("intents %q, want %q", intents, want)
+	}
+
+	// The result and the builder do not share their states.
+	flows["order_pizza"].State.Message[0] = "changed"
+	flows["choice_pizza_toppings"].Choice.Options["3"] = "end"
+	intents["order_pizza"][0] = "changed"
+	b.Say("Bye")
+	again, againIntents, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again["order_pizza"].State.Message[0] != "How many pizzas?" || len(again["choice_pizza_toppings"].Choice.Options) != 2 {
+		t.Errorf("changing the result changed the builder: %v", again)
+	}
+	if againIntents["order_pizza"][0] != "I want pizza" {
+		t.Errorf("changing the intents changed the builder: %q", againIntents)
+	}
+	if got := flows["pizza_toppings"].State.Message; len(got) != 1 {
+		t.Errorf("changing the builder changed the result: %q", got)
+	}
+}
+
+func TestFlowBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *FlowBuilder
+		problems []string
+	}{
+		{
+			"duplicate state",
+			NewFlow().State("a").End().State("a").End(),
+			[]string{`state "a" is defined twice`},
+		},
+		{
+			"reserved and empty names",
+			NewFlow().State(EndState).State(""),
+			[]string{`state name "end" is reserved`, "state name is empty"},
+		},
+		{
+			"option without a choice",
+			NewFlow().State("a").Option("1", "b").End(),
+			[]string{"Option called outside of a Choice"},
+		},
+		{
+			"say and fallback in the wrong state",
+			NewFlow().Say("hi").Choice("c").Say("hi").Option("1", EndState).State("a").Fallback("?").End(),
+			[]string{"Say called outside of a State", "Say called outside of a State", "Fallback called outside of a Choice"},
+		},
+		{
+			"duplicate option",
+			NewFlow().Choice("c").Option("1", EndState).Option("1", EndState),
+			[]string{`choice "c" has option "1" twice`},
+		},
+		{
+			"intent before state",
+			NewFlow().Intent("hi").State("a").End(),
+			[]string{"Intent called before State"},
+		},
+		{
+			"unknown next state",
+			NewFlow().State("a").Next("b").Choice("c").Option("1", "d"),
+			[]string{`state "a" moves to unknown state "b"`, `state "c" moves to unknown state "d"`},
+		},
+		{
+			"missing next state and options",
+			NewFlow().State("a").Say("hi").Choice("c"),
+			[]string{`state "a" has no next state`, `choice "c" has no options`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flows, intents, err := tt.builder.Build()
+			var buildErr *FlowBuildError
+			if !errors.As(err, &buildErr) {
+				t.Fatalf("err = %v, want a FlowBuildError", err)
+			}
+			if !reflect.DeepEqual(buildErr.Problems, tt.problems) {
+				t.Errorf("problems %q, want %q", buildErr.Problems, tt.problems)
+			}
+			if flows != nil || intents != nil {
+				t.Errorf("invalid flows returned: %v %v", flows, intents)
+			}
+		})
+	}
+}
+
+func TestFlowBuilderApply(t *testing.T) {
+	bot := &Bot{
+		Id:      1,
+		Intents: map[string][]string{"greets": {"hi"}, "goodbye": {"bye"}},
+		Flows: Flows{
+			"greets":  {State: &FlowState{Message: []string{"Hello"}, NextState: EndState}},
+			"goodbye": {State: &FlowState{Message: []string{"Bye"}, NextState: EndState}},
+		},
+	}
+	err := NewFlow().State("greets").Intent("hey").Say("Hey there").End().Apply(bot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"hey"}; !reflect.DeepEqual(bot.Intents["greets"], want) {
+		t.Errorf("greets examples %q, want %q", bot.Intents["greets"], want)
+	}
+	if got := bot.Flows["greets"].State.Message; !reflect.DeepEqual(got, []string{"Hey there"}) {
+		t.Errorf("greets messages %q", got)
+	}
+	if _, ok := bot.Flows["goodbye"]; !ok || len(bot.Intents["goodbye"]) != 1 {
+		t.Error("other flows and intents were removed")
+	}
+
+	if err := NewFlow().State("a").Apply(bot); err == nil {
+		t.Error("invalid flows applied")
+	}
+	if _, ok := bot.Flows["a"]; ok {
+		t.Error("invalid flows changed the bot")
+	}
+	if err := NewFlow().State("a").End().Apply(&Bot{}); err == nil {
+		t.Error("flows applied to a bot without an ID")
+	}
+
+	empty := &Bot{Id: 2}
+	if err := NewFlow().State("a").End().Apply(empty); err != nil || len(empty.Flows) != 1 || empty.Intents == nil {
+		t.Errorf("apply to a bot without flows: %v, %+v", err, empty)
+	}
+}
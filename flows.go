@@ -0,0 +1,264 @@
+package sarufi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// FlowState is a flow node that sends one or more messages
+// and then moves the conversation to NextState.
+// See: https://docs.sarufi.io/docs/Getting%20started%20/create-a-simple-chatbot
+type FlowState struct {
+	Message   []string `json:"message"`
+	NextState string   `json:"next_state,omitempty"`
+}
+
+// ChoiceState is a flow node that moves the conversation to
+// the state mapped to the user's reply in Options. If the reply
+// is not one of the options, FallbackMessage is sent instead.
+// See: https://docs.sarufi.io/docs/Getting%20started%20/chatbots-addons#handling-choices
+type ChoiceState struct {
+	Options         map[string]string
+	FallbackMessage []string
+}
+
+// Flow is a single node of Bot.Flows. Exactly one of State and
+// Choice is set. Extra keeps any field this package does not know
+// about so that it is sent back unchanged on UpdateBot; so is a
+// next_state read as "" or null, unless NextState is set since.
+type Flow struct {
+	State  *FlowState
+	Choice *ChoiceState
+	Extra  map[string]json.RawMessage
+
+	// raw holds nodes that are not JSON objects at all.
+	raw json.RawMessage
+	// emptyNextState holds a next_state read as "" or null, so it
+	// is written back as it was.
+	emptyNextState json.RawMessage
+}
+
+// Flows maps a state name to its flow node. It reads and writes
+// the same JSON format as the Sarufi API.
+type Flows map[string]Flow
+
+// IsChoice reports whether the flow is a choice state.
+func (f Flow) IsChoice() bool {
+	return f.Choice != nil
+}
+
+// Targets returns the states the flow can move to, sorted.
+func (f Flow) Targets() []string {
+	var targets []string
+	if f.State != nil && f.State.NextState != "" {
+		targets = append(targets, f.State.NextState)
+	}
+	if f.Choice != nil {
+		seen := make(map[string]bool)
+		for _, target := range f.Choice.Options {
+			if !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+		sort.Strings(targets)
+	}
+	return targets
+}
+
+// MarshalJSON writes the flow in the Sarufi wire format.
+func (f Flow) MarshalJSON() ([]byte, error) {
+	if f.raw != nil {
+		return f.raw, nil
+	}
+
+	fields := make(map[string]interface{}, len(f.Extra)+2)
+	for k, v := range f.Extra {
+		fields[k] = v
+	}
+
+	switch {
+	case f.State != nil:
+		message := f.State.Message
+		if message == nil {
+			message = []string{}
+		}
+		fields["message"] = message
+		switch {
+		case f.State.NextState != "":
+			fields["next_state"] = f.State.NextState
+		case f.emptyNextState != nil:
+			fields["next_state"] = f.emptyNextState
+		}
+	case f.Choice != nil:
+		for option, target := range f.Choice.Options {
+			fields[option] = target
+		}
+		if f.Choice.FallbackMessage != nil {
+			fields["fallback_message"] = f.Choice.FallbackMessage
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON reads a flow in the Sarufi wire format. A node
+// with a "message" or "next_state" field is a FlowState, any
+// other node is a ChoiceState.
+func (f *Flow) UnmarshalJSON(data []byte) error {
+	*f = Flow{}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		f.raw = append(json.RawMessage(nil), trimmed...)
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	_, hasMessage := fields["message"]
+	_, hasNextState := fields["next_state"]
+	if hasMessage || hasNextState {
+		f.State = &FlowState{}
+		for k, v := range fields {
+			var err error
+			switch k {
+			case "message":
+				err = json.Unmarshal(v, &f.State.Message)
+			case "next_state":
+				err = json.Unmarshal(v, &f.State.NextState)
+				if err == nil && f.State.NextState == "" {
+					f.emptyNextState = append(json.RawMessage(nil), v...)
+				}
+			default:
+				f.addExtra(k, v)
+			}
+			if err != nil {
+				return fmt.Errorf("flow field %q: %w", k, err)
+			}
+		}
+		return nil
+	}
+
+	f.Choice = &ChoiceState{Options: make(map[string]string)}
+	for k, v := range fields {
+		if k == "fallback_message" {
+			if err := json.Unmarshal(v, &f.Choice.FallbackMessage); err != nil {
+				return fmt.Errorf("flow field %q: %w", k, err)
+			}
+			continue
+		}
+		var target string
+		if err := json.Unmarshal(v, &target); err != nil {
+			f.addExtra(k, v)
+			continue
+		}
+		f.Choice.Options[k] = target
+	}
+	return nil
+}
+
+// clone returns a copy of the flow sharing nothing with it.
+func (f Flow) clone() Flow {
+	var c Flow
+	if f.raw != nil {
+		c.raw = append(json.RawMessage(nil), f.raw...)
+	}
+	if f.emptyNextState != nil {
+		c.emptyNextState = append(json.RawMessage(nil), f.emptyNextState...)
+	}
+	if f.State != nil {
+		state := *f.State
+		state.Message = append([]string(nil), f.State.Message...)
+		c.State = &state
+	}
+	if f.Choice != nil {
+		choice := ChoiceState{
+			Options:         make(map[string]string, len(f.Choice.Options)),
+			FallbackMessage: append([]string(nil), f.Choice.FallbackMessage...),
+		}
+		for reply, target := range f.Choice.Options {
+			choice.Options[reply] = target
+		}
+		c.Choice = &choice
+	}
+	for key, value := range f.Extra {
+		c.addExtra(key, append(json.RawMessage(nil), value...))
+	}
+	return c
+}
+
+func (f *Flow) addExtra(key string, value json.RawMessage) {
+	if f.Extra == nil {
+		f.Extra = make(map[string]json.RawMessage)
+	}
+	f.Extra[key] = value
+}
+
+// MarshalJSON writes the flows in the Sarufi wire format.
+// A nil Flows is written as an empty object.
+func (fs Flows) MarshalJSON() ([]byte, error) {
+	if fs == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]Flow(fs))
+}
+
+// UnmarshalJSON reads flows in the Sarufi wire format.
+func (fs *Flows) UnmarshalJSON(data []byte) error {
+	var nodes map[string]Flow
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return err
+	}
+	if *fs == nil {
+		*fs = make(Flows, len(nodes))
+	}
+	for name, flow := range nodes {
+		(*fs)[name] = flow
+	}
+	return nil
+}
+
+// toFlow converts the values accepted by AddFlow into a Flow.
+func toFlow(flow interface{}) (Flow, error) {
+	switch f := flow.(type) {
+	case Flow:
+		return f, nil
+	case *Flow:
+		return *f, nil
+	case FlowState:
+		return Flow{State: &f}, nil
+	case *FlowState:
+		return Flow{State: f}, nil
+	case ChoiceState:
+		return Flow{Choice: &f}, nil
+	case *ChoiceState:
+		return Flow{Choice: f}, nil
+	}
+
+	var data []byte
+	switch f := flow.(type) {
+	case string:
+		data = []byte(f)
+	case []byte:
+		data = f
+	default:
+		var err error
+		if data, err = json.Marshal(flow); err != nil {
+			return Flow{}, err
+		}
+	}
+
+	var result Flow
+	if err := json.Unmarshal(data, &result); err != nil {
+		return Flow{}, err
+	}
+	if result.raw != nil {
+		return Flow{}, fmt.Errorf("flow must be a JSON object, got %s", result.raw)
+	}
+	return result, nil
+}
@@ -0,0 +1,191 @@
+package sarufi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// pizzaFlows are the flows of the README.
+const pizzaFlows = `{
+	"greets": {"message": ["Hi, How can I help you?"], "next_state": "end"},
+	"order_pizza": {
+		"message": ["Sure, How many pizzas would you like to order?"],
+		"next_state": "number_of_pizzas"
+	},
+	"number_of_pizzas": {
+		"message": [
+			"Sure, What would you like to have on your pizza?",
+			"1. Cheese",
+			"2. Pepperoni",
+			"3. Both"
+		],
+		"next_state": "choice_pizza_toppings"
+	},
+	"choice_pizza_toppings": {
+		"1": "pizza_toppings",
+		"2": "pizza_toppings",
+		"3": "pizza_toppings",
+		"fallback_message": ["Sorry, the topping you chose is not available."]
+	},
+	"pizza_toppings": {
+		"message": ["Cool, Whats your address ?"],
+		"next_state": "address"
+	},
+	"address": {
+		"message": ["Sure, What is your phone number ?"],
+		"next_state": "phone_number"
+	},
+	"phone_number": {
+		"message": ["Your order has been placed.", "Thank you for ordering with us."],
+		"next_state": "end"
+	},
+	"goodbye": {"message": ["Bye", "See you soon"], "next_state": "end"}
+}`
+
+// pizzaIntents are the intents of the README.
+var pizzaIntents = map[string][]string{
+	"goodbye":     {"bye", "goodbye", "see ya"},
+	"greets":      {"hey", "hello", "hi"},
+	"order_pizza": {"I need pizza", "I want pizza"},
+}
+
+func TestFlowsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		flows string
+	}{
+		{"readme", pizzaFlows},
+		{"empty", `{}`},
+		{"state without next state", `{"goodbye": {"message": ["Bye"]}}`},
+		{"state with empty message", `{"wait": {"message": [], "next_state": "end"}}`},
+		{"empty next state", `{"goodbye": {"message": ["Bye"], "next_state": ""}}`},
+		{"null next state", `{"goodbye": {"message": ["Bye"], "next_state": null}}`},
+		{"choice without fallback", `{"pick": {"1": "one", "2": "two"}}`},
+		{"unknown state fields", `{"greets": {"message": ["Hi"], "next_state": "end", "delay": 2, "buttons": [{"id": "a"}]}}`},
+		{"unknown choice fields", `{"pick": {"1": "one", "fallback_message": ["No"], "meta": {"x": 1}, "retries": 3}}`},
+		{"nodes that are not objects", `{"odd": ["a", "b"], "nothing": null, "text": "hello"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flows Flows
+			if err := json.Unmarshal([]byte(tt.flows), &flows); err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(flows)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want, got interface{}
+			if err := json.Unmarshal([]byte(tt.flows), &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed the flows\ngot:  %s\nwant: %s", data, tt.flows)
+			}
+		})
+	}
+}
+
+func TestFlowsDecode(t *testing.T) {
+	var flows Flows
+	if err := json.Unmarshal([]byte(pizzaFlows), &flows); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		state   string
+		choice  bool
+		targets []string
+	}{
+		{"greets", false, []string{"end"}},
+		{"number_of_pizzas", false, []string{"choice_pizza_toppings"}},
+		{"choice_pizza_toppings", true, []string{"pizza_toppings"}},
+		{"phone_number", false, []string{"end"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			flow, ok := flows[tt.state]
+			if !ok {
+				t.Fatalf("state %q missing", tt.state)
+			}
+			if flow.IsChoice() != tt.choice {
+				t.Errorf("IsChoice() = %v, want %v", flow.IsChoice(), tt.choice)
+			}
+			if got := flow.Targets(); !reflect.DeepEqual(got, tt.targets) {
+				t.Errorf("Targets() = %v, want %v", got, tt.targets)
+			}
+		})
+	}
+
+	choice := flows["choice_pizza_toppings"].Choice
+	if want := []string{"Sorry, the topping you chose is not available."}; !reflect.DeepEqual(choice.FallbackMessage, want) {
+		t.Errorf("FallbackMessage = %v, want %v", choice.FallbackMessage, want)
+	}
+}
+
+func TestFlowsFromGoValues(t *testing.T) {
+	tests := []struct {
+		name string
+		flow interface{}
+		want string
+	}{
+		{"FlowState", FlowState{Message: []string{"Bye"}, NextState: "end"}, `{"message":["Bye"],"next_state":"end"}`},
+		{"FlowState without message", &FlowState{NextState: "end"}, `{"message":[],"next_state":"end"}`},
+		{"ChoiceState", ChoiceState{Options: map[string]string{"1": "one"}, FallbackMessage: []string{"No"}}, `{"1":"one","fallback_message":["No"]}`},
+		{"JSON string", `{"message": ["Hi"]}`, `{"message":["Hi"]}`},
+		{"map", map[string]interface{}{"yes": "done"}, `{"yes":"done"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow, err := toFlow(tt.flow)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(flow)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+
+	if _, err := toFlow(`["not", "an", "object"]`); err == nil {
+		t.Error("toFlow accepted a JSON array")
+	}
+}
+
+func TestFlowsEmptyNextState(t *testing.T) {
+	var flows Flows
+	if err := json.Unmarshal([]byte(`{"goodbye": {"message": ["Bye"], "next_state": ""}}`), &flows); err != nil {
+		t.Fatal(err)
+	}
+	if flows["goodbye"].State.NextState != "" {
+		t.Fatalf("NextState = %q", flows["goodbye"].State.NextState)
+	}
+
+	// A next state set afterwards replaces the empty one.
+	flows["goodbye"].State.NextState = "end"
+	data, err := json.Marshal(flows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"goodbye":{"message":["Bye"],"next_state":"end"}}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	// Flows built in Go leave an empty next state out.
+	data, err = json.Marshal(Flows{"goodbye": {State: &FlowState{Message: []string{"Bye"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"goodbye":{"message":["Bye"]}}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
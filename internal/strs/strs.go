@@ -0,0 +1,39 @@
+// Package strs holds the helpers on string slices and string keyed
+// maps shared by the packages of this module.
+package strs
+
+import "sort"
+
+// Equal reports whether a and b hold the same strings in the same
+// order. A nil slice equals an empty one.
+func Equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// AppendUnique appends value to list unless list holds it already.
+func AppendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// SortedKeys returns the keys of m, sorted.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
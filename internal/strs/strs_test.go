@@ -0,0 +1,44 @@
+package strs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want bool
+	}{
+		{nil, nil, true},
+		{nil, []string{}, true},
+		{[]string{"a", "b"}, []string{"a", "b"}, true},
+		{[]string{"a", "b"}, []string{"b", "a"}, false},
+		{[]string{"a"}, []string{"a", "a"}, false},
+	}
+	for _, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAppendUnique(t *testing.T) {
+	var list []string
+	for _, value := range []string{"b", "a", "b", "c", "a"} {
+		list = AppendUnique(list, value)
+	}
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(list, want) {
+		t.Errorf("got %q, want %q", list, want)
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	got := SortedKeys(map[string]int{"pizza": 1, "drinks": 2, "greets": 3})
+	if want := []string{"drinks", "greets", "pizza"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := SortedKeys(map[string]bool(nil)); len(got) != 0 {
+		t.Errorf("got %q for a nil map", got)
+	}
+}
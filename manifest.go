@@ -0,0 +1,131 @@
+package sarufi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is the definition of a bot kept in a file, so bots can
+// live in version control. Manifests are written in YAML or JSON:
+//
+//	name: Pizza
+//	description: Orders pizza
+//	industry: Food
+//	intents:
+//	  order_pizza: ["I want pizza", "I need pizza"]
+//	flows:
+//	  order_pizza:
+//	    message: ["How many pizzas do you want?"]
+//	    next_state: end
+//	webhook:
+//	  url: https://example.com/webhook
+//	  trigger_intents: [order_pizza]
+type Manifest struct {
+	Name               string              `json:"name"`
+	Description        string              `json:"description,omitempty"`
+	Industry           string              `json:"industry,omitempty"`
+	VisibleOnCommunity bool                `json:"visible_on_community,omitempty"`
+	ModelName          string              `json:"model_name,omitempty"`
+	Webhook            *ManifestWebhook    `json:"webhook,omitempty"`
+	Intents            map[string][]string `json:"intents"`
+	Flows              Flows               `json:"flows"`
+}
+
+// ManifestWebhook is the webhook part of a Manifest.
+type ManifestWebhook struct {
+	URL            string   `json:"url"`
+	TriggerIntents []string `json:"trigger_intents,omitempty"`
+}
+
+// LoadManifest reads a manifest file. See ParseManifest.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// ParseManifest decodes a manifest written in YAML or JSON. Keys
+// are the same in both, and flows use the Sarufi flow format.
+func ParseManifest(data []byte) (*Manifest, error) {
+	// Going through JSON gives YAML manifests the JSON decoding of
+	// Flows, and JSON is valid YAML.
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(jsonData, &m); err != nil {
+		return nil, err
+	}
+	if m.Name == "" {
+		return nil, fmt.Errorf("manifest has no name")
+	}
+	if m.Intents == nil {
+		m.Intents = map[string][]string{}
+	}
+	if m.Flows == nil {
+		m.Flows = Flows{}
+	}
+	return &m, nil
+}
+
+// Validate checks the definition of the manifest.
+// See ValidateDefinition.
+func (m *Manifest) Validate() Issues {
+	var triggers []string
+	if m.Webhook != nil {
+		triggers = m.Webhook.TriggerIntents
+	}
+	return ValidateDefinition(m.Intents, m.Flows, triggers)
+}
+
+// ApplyTo sets the definition of the manifest on the bot: its
+// details, webhook, intents and flows. Intents and flows missing
+// from the manifest are removed. The ID and runtime fields of the
+// bot are left as is. For changes to take effect, call UpdateBot.
+func (m *Manifest) ApplyTo(bot *Bot) {
+	bot.Name = m.Name
+	bot.Description = m.Description
+	bot.Industry = m.Industry
+	bot.VisibleOnCommunity = m.VisibleOnCommunity
+	if m.ModelName != "" {
+		bot.ModelName = m.ModelName
+	}
+
+	bot.WebhookURL = ""
+	bot.WebhookTriggerIntents = []string{}
+	if m.Webhook != nil {
+		bot.WebhookURL = m.Webhook.URL
+		bot.WebhookTriggerIntents = append(bot.WebhookTriggerIntents, m.Webhook.TriggerIntents...)
+	}
+
+	bot.Intents = make(map[string][]string, len(m.Intents))
+	for name, examples := range m.Intents {
+		bot.Intents[name] = append([]string{}, examples...)
+	}
+	bot.Flows = make(Flows, len(m.Flows))
+	for name, flow := range m.Flows {
+		bot.Flows[name] = flow
+	}
+}
+
+// Bot returns a bot without ID holding the manifest definition.
+func (m *Manifest) Bot() *Bot {
+	bot := &Bot{}
+	m.ApplyTo(bot)
+	return bot
+}
@@ -0,0 +1,120 @@
+package sarufi
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Types of the messages that can be sent to a bot.
+const (
+	MessageText        = "text"
+	MessageButtonReply = "button_reply"
+	MessageListReply   = "list_reply"
+	MessageLocation    = "location"
+	MessageImage       = "image"
+	MessageVideo       = "video"
+	MessageAudio       = "audio"
+	MessageDocument    = "document"
+	MessageSticker     = "sticker"
+)
+
+// Message is a message sent by a user to a bot. Build it with
+// TextMessage, ButtonReply, ListReply, Location or Media and send
+// it with Session.SendMessage or Bot.SendMessage.
+//
+// The "message" field of the request always holds the text the
+// bot matches intents and choices against: the text itself, the ID
+// of the picked button or row, "latitude,longitude" for locations
+// and the caption or link for media. The details are sent in a
+// field named after the message type.
+type Message struct {
+	Type string
+
+	// Text is the text of a text message, or the caption of media.
+	Text string
+
+	// ID, Title and Description describe the picked button or row.
+	ID          string
+	Title       string
+	Description string
+
+	Latitude  float64
+	Longitude float64
+
+	// URL is the link to the media file.
+	URL string
+}
+
+// TextMessage returns a plain text message.
+func TextMessage(text string) Message {
+	return Message{Type: MessageText, Text: text}
+}
+
+// ButtonReply returns the reply of a user tapping a reply button.
+func ButtonReply(id, title string) Message {
+	return Message{Type: MessageButtonReply, ID: id, Title: title}
+}
+
+// ListReply returns the reply of a user picking a row of a list.
+func ListReply(id, title, description string) Message {
+	return Message{Type: MessageListReply, ID: id, Title: title, Description: description}
+}
+
+// Location returns a location pin.
+func Location(latitude, longitude float64) Message {
+	return Message{Type: MessageLocation, Latitude: latitude, Longitude: longitude}
+}
+
+// Media returns a media message of the given kind, one of
+// MessageImage, MessageVideo, MessageAudio, MessageDocument or
+// MessageSticker, pointing at url.
+func Media(kind, url string) Message {
+	return Message{Type: kind, URL: url}
+}
+
+// WithCaption returns a copy of a media message with a caption.
+func (m Message) WithCaption(caption string) Message {
+	m.Text = caption
+	return m
+}
+
+// payload returns the fields the message adds to a
+// conversation request.
+func (m Message) payload() (map[string]interface{}, error) {
+	params := map[string]interface{}{"message_type": m.Type}
+	switch m.Type {
+	case MessageText:
+		params["message"] = m.Text
+	case MessageButtonReply, MessageListReply:
+		if m.ID == "" {
+			return nil, fmt.Errorf("%s message has no id", m.Type)
+		}
+		reply := map[string]string{"id": m.ID, "title": m.Title}
+		if m.Type == MessageListReply && m.Description != "" {
+			reply["description"] = m.Description
+		}
+		params["message"] = m.ID
+		params[m.Type] = reply
+	case MessageLocation:
+		params["message"] = strconv.FormatFloat(m.Latitude, 'f', -1, 64) + "," +
+			strconv.FormatFloat(m.Longitude, 'f', -1, 64)
+		params[m.Type] = map[string]float64{
+			"latitude":  m.Latitude,
+			"longitude": m.Longitude,
+		}
+	case MessageImage, MessageVideo, MessageAudio, MessageDocument, MessageSticker:
+		if m.URL == "" {
+			return nil, fmt.Errorf("%s message has no url", m.Type)
+		}
+		media := map[string]string{"link": m.URL}
+		params["message"] = m.URL
+		if m.Text != "" {
+			media["caption"] = m.Text
+			params["message"] = m.Text
+		}
+		params[m.Type] = media
+	default:
+		return nil, fmt.Errorf("unknown message type %q", m.Type)
+	}
+	return params, nil
+}
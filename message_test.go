@@ -0,0 +1,101 @@
+package sarufi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMessagePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		message Message
+		want    string
+	}{
+		{
+			"text",
+			TextMessage("I want pizza"),
+			`{"message_type": "text", "message": "I want pizza"}`,
+		},
+		{
+			"button reply",
+			ButtonReply("1", "Cheese"),
+			`{"message_type": "button_reply", "message": "1", "button_reply": {"id": "1", "title": "Cheese"}}`,
+		},
+		{
+			"list reply",
+			ListReply("row_2", "Pepperoni", "Spicy"),
+			`{"message_type": "list_reply", "message": "row_2", "list_reply": {"id": "row_2", "title": "Pepperoni", "description": "Spicy"}}`,
+		},
+		{
+			"list reply without description",
+			ListReply("row_1", "Cheese", ""),
+			`{"message_type": "list_reply", "message": "row_1", "list_reply": {"id": "row_1", "title": "Cheese"}}`,
+		},
+		{
+			"location",
+			Location(-6.7924, 39.2083),
+			`{"message_type": "location", "message": "-6.7924,39.2083", "location": {"latitude": -6.7924, "longitude": 39.2083}}`,
+		},
+		{
+			"media",
+			Media(MessageImage, "https://example.com/menu.png"),
+			`{"message_type": "image", "message": "https://example.com/menu.png", "image": {"link": "https://example.com/menu.png"}}`,
+		},
+		{
+			"media with caption",
+			Media(MessageDocument, "https://example.com/menu.pdf").WithCaption("menu"),
+			`{"message_type": "document", "message": "menu", "document": {"link": "https://example.com/menu.pdf", "caption": "menu"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Error(err)
+				}
+				w.Write([]byte(`{"message": ["ok"], "next_state": "end"}`))
+			}))
+			defer server.Close()
+
+			bot := &Bot{Id: 7, ChatID: "chat"}
+			bot.SetClient(NewClient(WithAPIKey("key"), WithBaseURL(server.URL)))
+			if err := bot.SendMessage(context.Background(), tt.message, DefaultChannel); err != nil {
+				t.Fatal(err)
+			}
+
+			var want map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			want["chat_id"] = "chat"
+			want["bot_id"] = float64(7)
+			want["channel"] = DefaultChannel
+			if !reflect.DeepEqual(body, want) {
+				got, _ := json.Marshal(body)
+				wantJSON, _ := json.Marshal(want)
+				t.Errorf("body %s, want %s", got, wantJSON)
+			}
+			if !reflect.DeepEqual(bot.Conversation.Message, []string{"ok"}) {
+				t.Errorf("Conversation = %+v", bot.Conversation)
+			}
+		})
+	}
+}
+
+func TestMessagePayloadErrors(t *testing.T) {
+	for _, message := range []Message{
+		ButtonReply("", "Cheese"),
+		ListReply("", "Cheese", ""),
+		Media(MessageVideo, ""),
+		{Type: "poll"},
+	} {
+		if _, err := message.payload(); err == nil {
+			t.Errorf("%+v: no error", message)
+		}
+	}
+}
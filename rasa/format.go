@@ -0,0 +1,105 @@
+package rasa
+
+import (
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// file is any Rasa training data or domain file. Rasa lets every
+// file hold any of these keys.
+type file struct {
+	NLU     []nluItem `yaml:"nlu"`
+	Stories []story   `yaml:"stories"`
+	Rules   []story   `yaml:"rules"`
+
+	Responses map[string][]response `yaml:"responses"`
+	Slots     map[string]slot       `yaml:"slots"`
+	Forms     map[string]yaml.Node  `yaml:"forms"`
+	Actions   []string              `yaml:"actions"`
+}
+
+// nluItem is an item of the nlu key.
+type nluItem struct {
+	Intent   string    `yaml:"intent"`
+	Examples yaml.Node `yaml:"examples"`
+	Synonym  string    `yaml:"synonym"`
+	Regex    string    `yaml:"regex"`
+	Lookup   string    `yaml:"lookup"`
+}
+
+// story is a story or a rule.
+type story struct {
+	Story     string      `yaml:"story"`
+	Rule      string      `yaml:"rule"`
+	Condition []yaml.Node `yaml:"condition"`
+	Steps     []step      `yaml:"steps"`
+}
+
+func (s *story) name() string {
+	if s.Rule != "" {
+		return s.Rule
+	}
+	return s.Story
+}
+
+// step is a step of a story or rule.
+type step struct {
+	Intent     string      `yaml:"intent"`
+	User       string      `yaml:"user"`
+	Action     string      `yaml:"action"`
+	Bot        string      `yaml:"bot"`
+	SlotWasSet []yaml.Node `yaml:"slot_was_set"`
+	ActiveLoop yaml.Node   `yaml:"active_loop"`
+	Checkpoint string      `yaml:"checkpoint"`
+	Or         []yaml.Node `yaml:"or"`
+}
+
+// response is a variation of a domain response.
+type response struct {
+	Text      string      `yaml:"text"`
+	Buttons   []yaml.Node `yaml:"buttons"`
+	Image     string      `yaml:"image"`
+	Custom    yaml.Node   `yaml:"custom"`
+	Condition []yaml.Node `yaml:"condition"`
+	Channel   string      `yaml:"channel"`
+}
+
+// slot is a domain slot.
+type slot struct {
+	Type     string `yaml:"type"`
+	Mappings []struct {
+		Type       string      `yaml:"type"`
+		Conditions []yaml.Node `yaml:"conditions"`
+	} `yaml:"mappings"`
+}
+
+// entityAnnotation matches [text](entity) and [text]{"entity": ...}.
+var entityAnnotation = regexp.MustCompile(`\[([^\]]*)\](?:\([^)]*\)|\{[^}]*\})`)
+
+// examples returns the examples of an intent without their
+// entity annotations. Rasa writes them as a block of "- " lines,
+// older files as a list.
+func (item *nluItem) examples() []string {
+	var lines []string
+	switch item.Examples.Kind {
+	case yaml.ScalarNode:
+		lines = strings.Split(item.Examples.Value, "\n")
+	case yaml.SequenceNode:
+		for _, node := range item.Examples.Content {
+			lines = append(lines, node.Value)
+		}
+	}
+
+	var examples []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimPrefix(line, "- "))
+		if line == "" || line == "-" {
+			continue
+		}
+		examples = append(examples, entityAnnotation.ReplaceAllString(line, "$1"))
+	}
+	return examples
+}
@@ -0,0 +1,383 @@
+// Package rasa imports the definition of a Rasa project into a
+// Sarufi bot. Intents and their examples come from the nlu data,
+// with entity annotations stripped, and flows from stories and
+// rules: each intent of a story becomes a flow state sending the
+// text of the responses that follow it, and moving to the state of
+// the next intent. Only linear conversations translate this way;
+// forms, custom actions, slots, checkpoints, branches and
+// conditions are reported as problems instead:
+//
+//	result, err := rasa.ImportDir("path/to/rasa/project")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, problem := range result.Problems {
+//		log.Println(problem)
+//	}
+//	result.Apply(bot)
+package rasa
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/internal/strs"
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of the problems reported by Import.
+const (
+	ProblemForm         = "form"
+	ProblemCustomAction = "custom_action"
+	ProblemSlot         = "slot"
+	ProblemCheckpoint   = "checkpoint"
+	ProblemBranch       = "branch"
+	ProblemCondition    = "condition"
+	ProblemResponse     = "response"
+	ProblemNLU          = "nlu"
+)
+
+// Problem is a construct of the Rasa project that could not be
+// translated, or only in part. Name is the form, action, slot,
+// story, response or intent it is about.
+type Problem struct {
+	Kind    string `json:"kind"`
+	File    string `json:"file,omitempty"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.File == "" {
+		return p.Message
+	}
+	return p.File + ": " + p.Message
+}
+
+// File is the name and content of a Rasa file.
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Result is an imported bot definition.
+type Result struct {
+	Intents  map[string][]string
+	Flows    sarufi.Flows
+	Problems []Problem
+}
+
+// Apply replaces the intents and flows of the bot with the
+// imported ones. For changes to take effect, call UpdateBot.
+func (r *Result) Apply(bot *sarufi.Bot) {
+	bot.Intents = make(map[string][]string, len(r.Intents))
+	for name, examples := range r.Intents {
+		bot.Intents[name] = append([]string{}, examples...)
+	}
+	bot.Flows = make(sarufi.Flows, len(r.Flows))
+	for name, flow := range r.Flows {
+		bot.Flows[name] = flow
+	}
+}
+
+// Manifest returns a manifest of a bot with the given name holding
+// the imported definition, ready for sarufi plan and apply.
+func (r *Result) Manifest(name string) *sarufi.Manifest {
+	bot := &sarufi.Bot{Name: name}
+	r.Apply(bot)
+	return &sarufi.Manifest{Name: name, Intents: bot.Intents, Flows: bot.Flows}
+}
+
+// ImportDir imports a Rasa project directory: its domain.yml, or
+// the files of its domain directory, and the YAML files found
+// under its data directory.
+func ImportDir(dir string) (*Result, error) {
+	var names []string
+	for _, name := range []string{"domain.yml", "domain.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			names = append(names, filepath.Join(dir, name))
+		}
+	}
+	for _, sub := range []string{"domain", "data"} {
+		root := filepath.Join(dir, sub)
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ext := filepath.Ext(path); !d.IsDir() && (ext == ".yml" || ext == ".yaml") {
+				names = append(names, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no Rasa files in %s", dir)
+	}
+
+	files := make([]File, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			rel = name
+		}
+		files = append(files, File{Name: rel, Data: data})
+	}
+	return Import(files...)
+}
+
+// Import imports Rasa files. Each file may hold nlu data, stories,
+// rules or domain keys, as in a Rasa project. Rules are translated
+// before stories, each in the order of the files.
+func Import(files ...File) (*Result, error) {
+	im := &importer{
+		result: &Result{
+			Intents: map[string][]string{},
+			Flows:   sarufi.Flows{},
+		},
+		responses: map[string][]response{},
+		forms:     map[string]bool{},
+		reported:  map[string]bool{},
+		origin:    map[string]string{},
+	}
+
+	parsed := make([]file, len(files))
+	for i, f := range files {
+		if err := yaml.Unmarshal(f.Data, &parsed[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+
+	for i, f := range parsed {
+		im.readDomain(files[i].Name, &f)
+	}
+	for i, f := range parsed {
+		im.readNLU(files[i].Name, f.NLU)
+	}
+	for i, f := range parsed {
+		for j := range f.Rules {
+			im.readStory(files[i].Name, &f.Rules[j])
+		}
+	}
+	for i, f := range parsed {
+		for j := range f.Stories {
+			im.readStory(files[i].Name, &f.Stories[j])
+		}
+	}
+	return im.result, nil
+}
+
+// importer holds the state of an Import.
+type importer struct {
+	result    *Result
+	responses map[string][]response
+	forms     map[string]bool
+	// reported keeps the problems already reported.
+	reported map[string]bool
+	// origin names the story that defined each flow state.
+	origin map[string]string
+}
+
+func (im *importer) report(kind, fileName, name, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	key := kind + "\x00" + name + "\x00" + message
+	if im.reported[key] {
+		return
+	}
+	im.reported[key] = true
+	im.result.Problems = append(im.result.Problems, Problem{
+		Kind:    kind,
+		File:    fileName,
+		Name:    name,
+		Message: message,
+	})
+}
+
+func (im *importer) readDomain(fileName string, f *file) {
+	for name, variations := range f.Responses {
+		im.responses[name] = variations
+	}
+	for _, name := range strs.SortedKeys(f.Forms) {
+		im.forms[name] = true
+		im.report(ProblemForm, fileName, name, "form %s is not translated, Sarufi flows have no forms", name)
+	}
+	for _, name := range strs.SortedKeys(f.Slots) {
+		for _, mapping := range f.Slots[name].Mappings {
+			if len(mapping.Conditions) > 0 {
+				im.report(ProblemSlot, fileName, name, "slot %s is filled under conditions, which are not translated", name)
+			}
+		}
+	}
+	for _, action := range f.Actions {
+		if !strings.HasPrefix(action, "utter_") {
+			im.report(ProblemCustomAction, fileName, action, "custom action %s is not translated, use a webhook instead", action)
+		}
+	}
+}
+
+func (im *importer) readNLU(fileName string, items []nluItem) {
+	for _, item := range items {
+		switch {
+		case item.Intent != "":
+			examples := item.examples()
+			for _, example := range examples {
+				im.result.Intents[item.Intent] = strs.AppendUnique(im.result.Intents[item.Intent], example)
+			}
+			if len(examples) == 0 {
+				im.report(ProblemNLU, fileName, item.Intent, "intent %s has no examples", item.Intent)
+			}
+		case item.Synonym != "":
+			im.report(ProblemNLU, fileName, item.Synonym, "synonym %s is not translated", item.Synonym)
+		case item.Regex != "":
+			im.report(ProblemNLU, fileName, item.Regex, "regex %s is not translated", item.Regex)
+		case item.Lookup != "":
+			im.report(ProblemNLU, fileName, item.Lookup, "lookup table %s is not translated", item.Lookup)
+		}
+	}
+}
+
+// turn is an intent of a story and the responses that follow it.
+type turn struct {
+	intent   string
+	messages []string
+}
+
+// readStory translates a story or rule into flow states. The
+// story is cut at the first step that cannot be translated.
+func (im *importer) readStory(fileName string, s *story) {
+	name := s.name()
+	if len(s.Condition) > 0 {
+		im.report(ProblemCondition, fileName, name, "rule %q has a condition and is not translated", name)
+		return
+	}
+
+	var turns []turn
+steps:
+	for _, st := range s.Steps {
+		switch {
+		case st.Intent != "":
+			turns = append(turns, turn{intent: st.Intent})
+		case st.User != "":
+			im.report(ProblemNLU, fileName, name, "story %q uses end-to-end user messages, cut there", name)
+			break steps
+		case st.Checkpoint != "":
+			im.report(ProblemCheckpoint, fileName, name, "story %q uses checkpoint %s, cut there", name, st.Checkpoint)
+			break steps
+		case len(st.Or) > 0:
+			im.report(ProblemBranch, fileName, name, "story %q has an or step, cut there", name)
+			break steps
+		case st.ActiveLoop.Kind != 0:
+			im.report(ProblemForm, fileName, name, "story %q runs a form, cut there", name)
+			break steps
+		case len(st.SlotWasSet) > 0:
+			im.report(ProblemSlot, fileName, name, "story %q depends on slots, which are ignored", name)
+		case st.Bot != "":
+			if len(turns) > 0 {
+				last := &turns[len(turns)-1]
+				last.messages = append(last.messages, st.Bot)
+			}
+		case st.Action != "":
+			if im.forms[st.Action] {
+				im.report(ProblemForm, fileName, name, "story %q runs form %s, cut there", name, st.Action)
+				break steps
+			}
+			if len(turns) == 0 {
+				continue
+			}
+			last := &turns[len(turns)-1]
+			last.messages = append(last.messages, im.utter(fileName, st.Action)...)
+		}
+	}
+
+	for i, t := range turns {
+		next := sarufi.EndState
+		if i+1 < len(turns) {
+			next = turns[i+1].intent
+		}
+		im.addState(fileName, name, t, next)
+	}
+}
+
+// utter returns the messages of an action.
+func (im *importer) utter(fileName, action string) []string {
+	switch action {
+	case "action_listen", "action_restart", "action_session_start", "action_default_fallback", "action_back":
+		return nil
+	}
+	if !strings.HasPrefix(action, "utter_") {
+		im.report(ProblemCustomAction, fileName, action, "custom action %s is not translated, use a webhook instead", action)
+		return nil
+	}
+
+	variations, ok := im.responses[action]
+	if !ok || len(variations) == 0 {
+		im.report(ProblemResponse, fileName, action, "response %s is not in the domain", action)
+		return nil
+	}
+	if len(variations) > 1 {
+		im.report(ProblemResponse, fileName, action, "response %s has %d variations, only the first is used", action, len(variations))
+	}
+	v := variations[0]
+	switch {
+	case len(v.Condition) > 0 || v.Channel != "":
+		im.report(ProblemResponse, fileName, action, "response %s depends on a condition or channel, which is ignored", action)
+	case len(v.Buttons) > 0:
+		im.report(ProblemResponse, fileName, action, "buttons of response %s are not translated", action)
+	case v.Image != "" || v.Custom.Kind != 0:
+		im.report(ProblemResponse, fileName, action, "images and custom payloads of response %s are not translated", action)
+	}
+	if strings.Contains(v.Text, "{") {
+		im.report(ProblemSlot, fileName, action, "response %s fills slots into its text, which is kept as is", action)
+	}
+	if v.Text == "" {
+		return nil
+	}
+	return []string{v.Text}
+}
+
+// addState adds the flow state of a turn. A state already defined
+// by another story is kept, except that a state ending there is
+// continued; other differences are branches Sarufi flows cannot
+// express and are reported.
+func (im *importer) addState(fileName, storyName string, t turn, next string) {
+	messages := t.messages
+	if messages == nil {
+		messages = []string{}
+	}
+	existing, ok := im.result.Flows[t.intent]
+	if !ok {
+		im.result.Flows[t.intent] = sarufi.Flow{State: &sarufi.FlowState{Message: messages, NextState: next}}
+		im.origin[t.intent] = storyName
+		return
+	}
+
+	state := existing.State
+	if !strs.Equal(state.Message, messages) {
+		im.report(ProblemBranch, fileName, t.intent,
+			"intent %s answers differently in %q and %q, the first is kept", t.intent, im.origin[t.intent], storyName)
+		return
+	}
+	switch {
+	case state.NextState == next || next == sarufi.EndState:
+	case state.NextState == sarufi.EndState:
+		state.NextState = next
+		im.origin[t.intent] = storyName
+	default:
+		im.report(ProblemBranch, fileName, t.intent,
+			"after intent %s, %q goes to %s and %q to %s; flows cannot branch on intents, the first is kept",
+			t.intent, im.origin[t.intent], state.NextState, storyName, next)
+	}
+}
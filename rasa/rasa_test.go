@@ -0,0 +1,290 @@
+package rasa_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/rasa"
+)
+
+const domainYAML = `version: "3.1"
+intents:
+- greet
+- order_pizza
+- give_number
+- goodbye
+responses:
+  utter_greet:
+  - text: Hello! What can I get you?
+  utter_ask_number:
+  - text: How many pizzas?
+  utter_thanks:
+  - text: Thanks for your order
+  utter_goodbye:
+  - text: Bye
+`
+
+const nluYAML = `version: "3.1"
+nlu:
+- intent: greet
+  examples: |
+    - hi
+    - hello
+- intent: order_pizza
+  examples: |
+    - I want a [large](size) pizza
+    - [two]{"entity": "number"} pizzas please
+- intent: give_number
+  examples: |
+    - [3](number)
+- intent: goodbye
+  examples: |
+    - bye
+`
+
+// moreNLUYAML repeats the greet intent in the older list format.
+const moreNLUYAML = `nlu:
+- intent: greet
+  examples:
+  - hey
+  - hi
+`
+
+const storiesYAML = `version: "3.1"
+stories:
+- story: greet only
+  steps:
+  - intent: greet
+  - action: utter_greet
+- story: order
+  steps:
+  - intent: greet
+  - action: utter_greet
+  - intent: order_pizza
+  - action: utter_ask_number
+  - intent: give_number
+  - action: utter_thanks
+rules:
+- rule: say goodbye
+  steps:
+  - intent: goodbye
+  - action: utter_goodbye
+`
+
+// state is a flow state as Import writes it, with an empty list
+// for no messages.
+func state(next string, message ...string) sarufi.Flow {
+	if message == nil {
+		message = []string{}
+	}
+	return sarufi.Flow{State: &sarufi.FlowState{Message: message, NextState: next}}
+}
+
+func TestImport(t *testing.T) {
+	result, err := rasa.Import(
+		rasa.File{Name: "domain.yml", Data: []byte(domainYAML)},
+		rasa.File{Name: "data/nlu.yml", Data: []byte(nluYAML)},
+		rasa.File{Name: "data/more_nlu.yml", Data: []byte(moreNLUYAML)},
+		rasa.File{Name: "data/stories.yml", Data: []byte(storiesYAML)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantIntents := map[string][]string{
+		"greet":       {"hi", "hello", "hey"},
+		"order_pizza": {"I want a large pizza", "two pizzas please"},
+		"give_number": {"3"},
+		"goodbye":     {"bye"},
+	}
+	if !reflect.DeepEqual(result.Intents, wantIntents) {
+		t.Errorf("Intents = %q, want %q", result.Intents, wantIntents)
+	}
+
+	// "greet only" ends at greet, "order" continues it.
+	wantFlows := sarufi.Flows{
+		"greet":       state("order_pizza", "Hello! What can I get you?"),
+		"order_pizza": state("give_number", "How many pizzas?"),
+		"give_number": state(sarufi.EndState, "Thanks for your order"),
+		"goodbye":     state(sarufi.EndState, "Bye"),
+	}
+	if !reflect.DeepEqual(result.Flows, wantFlows) {
+		got, _ := result.Flows.MarshalJSON()
+		want, _ := wantFlows.MarshalJSON()
+		t.Errorf("Flows = %s, want %s", got, want)
+	}
+	if len(result.Problems) != 0 {
+		t.Errorf("Problems = %v", result.Problems)
+	}
+
+	bot := &sarufi.Bot{Name: "Pizza"}
+	result.Apply(bot)
+	if !reflect.DeepEqual(bot.Intents, wantIntents) || len(bot.Flows) != len(wantFlows) {
+		t.Errorf("applied bot %+v", bot)
+	}
+	bot.Intents["greet"][0] = "changed"
+	if result.Intents["greet"][0] != "hi" {
+		t.Error("Apply shares the examples with the result")
+	}
+}
+
+func TestImportDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"domain.yml":         domainYAML,
+		"data/nlu.yml":       nluYAML,
+		"data/stories.yml":   storiesYAML,
+		"data/README.md":     "not Rasa data",
+		"config/ignored.yml": "nlu: [{intent: ignored, examples: '- x'}]",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := rasa.ImportDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Intents) != 4 || len(result.Flows) != 4 || len(result.Problems) != 0 {
+		t.Errorf("result %+v", result)
+	}
+
+	if _, err := rasa.ImportDir(t.TempDir()); err == nil {
+		t.Error("empty directory imported")
+	}
+	if _, err := rasa.Import(rasa.File{Name: "bad.yml", Data: []byte("nlu: [")}); err == nil {
+		t.Error("invalid YAML imported")
+	}
+}
+
+func TestImportProblems(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		kinds []string
+		flows sarufi.Flows
+	}{
+		{
+			"form",
+			"forms:\n  pizza_form:\n    required_slots: [size]\nstories:\n- story: s\n  steps:\n  - intent: greet\n  - action: utter_greet\n  - action: pizza_form\n  - intent: goodbye\n",
+			[]string{rasa.ProblemForm + " pizza_form", rasa.ProblemForm + " s"},
+			sarufi.Flows{"greet": state(sarufi.EndState, "Hello! What can I get you?")},
+		},
+		{
+			"active loop",
+			"stories:\n- story: s\n  steps:\n  - intent: greet\n  - active_loop: pizza_form\n",
+			[]string{rasa.ProblemForm + " s"},
+			sarufi.Flows{"greet": state(sarufi.EndState)},
+		},
+		{
+			"custom action",
+			"actions:\n- utter_greet\n- action_order\nstories:\n- story: s\n  steps:\n  - intent: greet\n  - action: action_order\n  - action: action_listen\n",
+			[]string{rasa.ProblemCustomAction + " action_order"},
+			sarufi.Flows{"greet": state(sarufi.EndState)},
+		},
+		{
+			"slot conditions",
+			"slots:\n  size:\n    type: text\n    mappings:\n    - type: from_text\n      conditions:\n      - active_loop: pizza_form\n",
+			[]string{rasa.ProblemSlot + " size"},
+			sarufi.Flows{},
+		},
+		{
+			"slot was set",
+			"stories:\n- story: s\n  steps:\n  - intent: greet\n  - slot_was_set:\n    - size: large\n  - action: utter_greet\n",
+			[]string{rasa.ProblemSlot + " s"},
+			sarufi.Flows{"greet": state(sarufi.EndState, "Hello! What can I get you?")},
+		},
+		{
+			"checkpoint",
+			"stories:\n- story: s\n  steps:\n  - intent: greet\n  - action: utter_greet\n  - checkpoint: ordered\n  - intent: goodbye\n",
+			[]string{rasa.ProblemCheckpoint + " s"},
+			sarufi.Flows{"greet": state(sarufi.EndState, "Hello! What can I get you?")},
+		},
+		{
+			"or step",
+			"stories:\n- story: s\n  steps:\n  - intent: greet\n  - or:\n    - intent: goodbye\n    - intent: order_pizza\n",
+			[]string{rasa.ProblemBranch + " s"},
+			sarufi.Flows{"greet": state(sarufi.EndState)},
+		},
+		{
+			"end-to-end message",
+			"stories:\n- story: s\n  steps:\n  - intent: greet\n  - user: I want pizza\n",
+			[]string{rasa.ProblemNLU + " s"},
+			sarufi.Flows{"greet": state(sarufi.EndState)},
+		},
+		{
+			"rule condition",
+			"rules:\n- rule: r\n  condition:\n  - active_loop: pizza_form\n  steps:\n  - intent: greet\n",
+			[]string{rasa.ProblemCondition + " r"},
+			sarufi.Flows{},
+		},
+		{
+			"missing response",
+			"stories:\n- story: s\n  steps:\n  - intent: greet\n  - action: utter_missing\n",
+			[]string{rasa.ProblemResponse + " utter_missing"},
+			sarufi.Flows{"greet": state(sarufi.EndState)},
+		},
+		{
+			"response variations, buttons and slots",
+			"responses:\n  utter_size:\n  - text: \"{name}, which size?\"\n    buttons:\n    - title: Large\n  - text: Which size?\nstories:\n- story: s\n  steps:\n  - intent: greet\n  - action: utter_size\n",
+			[]string{rasa.ProblemResponse + " utter_size", rasa.ProblemResponse + " utter_size", rasa.ProblemSlot + " utter_size"},
+			sarufi.Flows{"greet": state(sarufi.EndState, "{name}, which size?")},
+		},
+		{
+			"nlu data",
+			"nlu:\n- intent: empty\n  examples: \"\"\n- synonym: large\n  examples: \"- big\"\n- regex: number\n  examples: \"- \\\\d+\"\n- lookup: sizes\n  examples: \"- small\"\n",
+			[]string{rasa.ProblemNLU + " empty", rasa.ProblemNLU + " large", rasa.ProblemNLU + " number", rasa.ProblemNLU + " sizes"},
+			sarufi.Flows{},
+		},
+		{
+			"different answers",
+			"stories:\n- story: a\n  steps:\n  - intent: greet\n  - action: utter_greet\n- story: b\n  steps:\n  - intent: greet\n  - action: utter_goodbye\n",
+			[]string{rasa.ProblemBranch + " greet"},
+			sarufi.Flows{"greet": state(sarufi.EndState, "Hello! What can I get you?")},
+		},
+		{
+			"branch on intents",
+			"stories:\n- story: a\n  steps:\n  - intent: greet\n  - intent: order_pizza\n- story: b\n  steps:\n  - intent: greet\n  - intent: goodbye\n",
+			[]string{rasa.ProblemBranch + " greet"},
+			sarufi.Flows{
+				"greet":       state("order_pizza"),
+				"order_pizza": state(sarufi.EndState),
+				"goodbye":     state(sarufi.EndState),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rasa.Import(
+				rasa.File{Name: "domain.yml", Data: []byte(domainYAML)},
+				rasa.File{Name: "data/test.yml", Data: []byte(tt.data)},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kinds []string
+			for _, p := range result.Problems {
+				kinds = append(kinds, p.Kind+" "+p.Name)
+				if p.File != "data/test.yml" || p.Message == "" {
+					t.Errorf("problem %+v", p)
+				}
+			}
+			if !reflect.DeepEqual(kinds, tt.kinds) {
+				t.Errorf("problems %q, want %q: %v", kinds, tt.kinds, result.Problems)
+			}
+			if !reflect.DeepEqual(result.Flows, tt.flows) {
+				got, _ := result.Flows.MarshalJSON()
+				want, _ := tt.flows.MarshalJSON()
+				t.Errorf("Flows = %s, want %s", got, want)
+			}
+		})
+	}
+}
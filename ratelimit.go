@@ -0,0 +1,166 @@
+package sarufi
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit configures the client side token bucket limiter.
+// Rate is the number of requests per second allowed across all
+// calls of the client, Burst the number of requests that may be
+// sent at once. PerBotRate and PerBotBurst do the same for every
+// bot ID separately. A zero rate means no limit.
+type RateLimit struct {
+	Rate        float64
+	Burst       int
+	PerBotRate  float64
+	PerBotBurst int
+}
+
+// WithRateLimit makes the client wait before sending requests
+// that would exceed the given limits. Waiting respects the
+// context deadline of the call.
+func WithRateLimit(limit RateLimit) Option {
+	return func(c *Client) {
+		c.limiter.limit = limit
+	}
+}
+
+// RateLimitStats tells whether calls are being slowed down by the
+// client side limiter or rejected by the server with 429 responses.
+type RateLimitStats struct {
+	// Waiting is the number of requests blocked by the limiter right now.
+	Waiting int
+	// Throttled is the number of requests that had to wait.
+	Throttled uint64
+	// TotalWait is the time spent waiting by all requests.
+	TotalWait time.Duration
+	// LastWait is the wait of the latest throttled request.
+	LastWait time.Duration
+	// ServerThrottled is the number of 429 responses received.
+	ServerThrottled uint64
+}
+
+// RateLimitStats returns a snapshot of the limiter metrics.
+func (c *Client) RateLimitStats() RateLimitStats {
+	c.limiter.mu.Lock()
+	defer c.limiter.mu.Unlock()
+	return c.limiter.stats
+}
+
+// bucket is a token bucket. Tokens may go negative, in which
+// case they are reservations made by waiting requests.
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// reserve takes a token and returns how long to wait for it.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// rateLimiter holds the global and per bot buckets of a client.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  RateLimit
+	global *bucket
+	perBot map[int]*bucket
+	stats  RateLimitStats
+
+	// now and sleep default to the real clock; tests replace them.
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+// wait blocks until the request may be sent. A botID of 0 only
+// uses the global bucket. If ctx ends first, the reserved tokens
+// are given back and the context error is returned.
+func (l *rateLimiter) wait(ctx context.Context, botID int) error {
+	l.mu.Lock()
+	var buckets []*bucket
+	if l.limit.Rate > 0 {
+		if l.global == nil {
+			l.global = newBucket(l.limit.Rate, l.limit.Burst)
+		}
+		buckets = append(buckets, l.global)
+	}
+	if l.limit.PerBotRate > 0 && botID != 0 {
+		if l.perBot == nil {
+			l.perBot = make(map[int]*bucket)
+		}
+		b, ok := l.perBot[botID]
+		if !ok {
+			b = newBucket(l.limit.PerBotRate, l.limit.PerBotBurst)
+			l.perBot[botID] = b
+		}
+		buckets = append(buckets, b)
+	}
+
+	now := time.Now()
+	if l.now != nil {
+		now = l.now()
+	}
+	var delay time.Duration
+	for _, b := range buckets {
+		if d := b.reserve(now); d > delay {
+			delay = d
+		}
+	}
+	if delay == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	l.stats.Waiting++
+	l.stats.Throttled++
+	l.stats.LastWait = delay
+	l.mu.Unlock()
+
+	wait := sleep
+	if l.sleep != nil {
+		wait = l.sleep
+	}
+	err := wait(ctx, delay)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Waiting--
+	if err != nil {
+		// Other requests may have refilled the buckets since.
+		for _, b := range buckets {
+			b.tokens = math.Min(b.tokens+1, b.burst)
+		}
+		return err
+	}
+	l.stats.TotalWait += delay
+	return nil
+}
+
+// serverThrottled records a 429 response.
+func (l *rateLimiter) serverThrottled() {
+	l.mu.Lock()
+	l.stats.ServerThrottled++
+	l.mu.Unlock()
+}
@@ -0,0 +1,162 @@
+package sarufi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for the limiter. Sleeping advances it,
+// unless block is set, in which case sleep waits for the context
+// after sending on sleeping.
+type fakeClock struct {
+	mu       sync.Mutex
+	t        time.Time
+	block    bool
+	sleeping chan time.Duration
+}
+
+func newLimiter(limit RateLimit) (*rateLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(0, 0), sleeping: make(chan time.Duration, 10)}
+	l := &rateLimiter{limit: limit, now: clock.now, sleep: clock.sleep}
+	return l, clock
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func (c *fakeClock) sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	block := c.block
+	c.mu.Unlock()
+	if block {
+		c.sleeping <- d
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	c.advance(d)
+	return nil
+}
+
+func TestRateLimiter(t *testing.T) {
+	type call struct {
+		botID int
+		after time.Duration
+		wait  time.Duration
+	}
+	tests := []struct {
+		name  string
+		limit RateLimit
+		calls []call
+	}{
+		{
+			"no limit",
+			RateLimit{},
+			[]call{{1, 0, 0}, {1, 0, 0}, {1, 0, 0}},
+		},
+		{
+			"global burst then rate",
+			RateLimit{Rate: 2, Burst: 2},
+			[]call{{0, 0, 0}, {1, 0, 0}, {2, 0, 500 * time.Millisecond}, {0, 0, 500 * time.Millisecond}, {0, time.Second, 0}},
+		},
+		{
+			"per bot",
+			RateLimit{PerBotRate: 1, PerBotBurst: 1},
+			[]call{{1, 0, 0}, {2, 0, 0}, {1, 0, time.Second}, {0, 0, 0}, {0, 0, 0}, {2, time.Second, 0}},
+		},
+		{
+			"global and per bot",
+			RateLimit{Rate: 10, Burst: 1, PerBotRate: 1, PerBotBurst: 1},
+			[]call{{1, 0, 0}, {2, 0, 100 * time.Millisecond}, {1, 0, 900 * time.Millisecond}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newLimiter(tt.limit)
+			var throttled uint64
+			var total time.Duration
+			for i, c := range tt.calls {
+				clock.advance(c.after)
+				start := clock.now()
+				if err := l.wait(context.Background(), c.botID); err != nil {
+					t.Fatal(err)
+				}
+				if waited := clock.now().Sub(start); waited != c.wait {
+					t.Errorf("call %d waited %v, want %v", i, waited, c.wait)
+				}
+				if c.wait > 0 {
+					throttled++
+					total += c.wait
+				}
+			}
+			stats := l.stats
+			if stats.Throttled != throttled || stats.TotalWait != total || stats.Waiting != 0 {
+				t.Errorf("stats %+v, want %d throttled for %v", stats, throttled, total)
+			}
+		})
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	l, clock := newLimiter(RateLimit{Rate: 1, Burst: 1, PerBotRate: 0.001, PerBotBurst: 1})
+	if err := l.wait(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	clock.block = true
+
+	// Both requests wait for bot 1. The second one comes once the
+	// global bucket is full again.
+	errs := make(chan error, 2)
+	var cancels []context.CancelFunc
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancels = append(cancels, cancel)
+		go func() { errs <- l.wait(ctx, 1) }()
+		<-clock.sleeping
+		clock.advance(100 * time.Second)
+	}
+	l.mu.Lock()
+	if l.stats.Waiting != 2 || l.stats.Throttled != 2 {
+		t.Errorf("stats while waiting %+v", l.stats)
+	}
+	l.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	}
+
+	if l.global.tokens > l.global.burst {
+		t.Errorf("global bucket holds %v tokens, burst is %v", l.global.tokens, l.global.burst)
+	}
+	if l.stats.Waiting != 0 || l.stats.TotalWait != 0 {
+		t.Errorf("stats after cancelling %+v", l.stats)
+	}
+
+	// Only one request goes through the global bucket at once.
+	clock.block = false
+	for i, want := range []time.Duration{0, time.Second} {
+		start := clock.now()
+		if err := l.wait(context.Background(), 0); err != nil {
+			t.Fatal(err)
+		}
+		if waited := clock.now().Sub(start); waited != want {
+			t.Errorf("call %d waited %v, want %v", i, waited, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package repl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sarufi-io/sarufi-golang-sdk"
+)
+
+// Backend is the bot a REPL talks to: the live API or a local
+// Engine. Send returns the reply of a turn as a Conversation, with
+// the current and next state of the chat filled in.
+type Backend interface {
+	ChatID() string
+	Send(ctx context.Context, message string) (sarufi.Conversation, error)
+	State(ctx context.Context) (sarufi.Conversation, error)
+	Predict(ctx context.Context, message string) (sarufi.Prediction, error)
+	History(ctx context.Context) ([]sarufi.ConversationHistory, error)
+	Reset()
+}
+
+// liveBackend talks to the API through a sarufi.Session.
+type liveBackend struct {
+	bot     *sarufi.Bot
+	session *sarufi.Session
+}
+
+// Live returns a Backend sending messages to the bot through the
+// API. An empty chat ID starts a new chat with a random ID.
+func Live(bot *sarufi.Bot, chatID string) Backend {
+	return &liveBackend{bot: bot, session: bot.Session(chatID)}
+}
+
+func (b *liveBackend) ChatID() string {
+	return b.session.ChatID()
+}
+
+// Send sends the message, then asks for the chat state, since
+// replies only carry the next state.
+func (b *liveBackend) Send(ctx context.Context, message string) (sarufi.Conversation, error) {
+	reply, err := b.session.Send(ctx, message)
+	if err != nil {
+		return sarufi.Conversation{}, err
+	}
+	conversation := sarufi.Conversation{
+		Message:   reply.Message,
+		Memory:    reply.Memory,
+		NextState: reply.NextState,
+	}
+	if state, err := b.session.State(ctx); err == nil {
+		conversation.CurrentState = state.CurrentState
+		if conversation.NextState == "" {
+			conversation.NextState = state.NextState
+		}
+	}
+	return conversation, nil
+}
+
+func (b *liveBackend) State(ctx context.Context) (sarufi.Conversation, error) {
+	return b.session.State(ctx)
+}
+
+func (b *liveBackend) Predict(ctx context.Context, message string) (sarufi.Prediction, error) {
+	return b.bot.PredictIntent(ctx, message)
+}
+
+func (b *liveBackend) History(ctx context.Context) ([]sarufi.ConversationHistory, error) {
+	return b.session.History(ctx)
+}
+
+func (b *liveBackend) Reset() {
+	b.session.Reset()
+}
+
+// offlineBackend runs the bot in a sarufi.Engine. It keeps the
+// history of the chat itself.
+type offlineBackend struct {
+	engine     *sarufi.Engine
+	classifier *sarufi.LocalClassifier
+
+	mu      sync.Mutex
+	chatID  string
+	history []sarufi.ConversationHistory
+}
+
+// Offline returns a Backend running the intents and flows of the
+// bot locally with a sarufi.Engine, without the API.
+func Offline(bot *sarufi.Bot, opts ...sarufi.EngineOption) Backend {
+	return &offlineBackend{
+		engine:     sarufi.NewEngine(bot, opts...),
+		classifier: sarufi.NewLocalClassifier(bot.Intents),
+		chatID:     uuid.New().String(),
+	}
+}
+
+func (b *offlineBackend) ChatID() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.chatID
+}
+
+func (b *offlineBackend) Send(ctx context.Context, message string) (sarufi.Conversation, error) {
+	if err := ctx.Err(); err != nil {
+		return sarufi.Conversation{}, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	conversation, err := b.engine.RespondContext(ctx, b.chatID, message)
+	if err != nil {
+		return sarufi.Conversation{}, err
+	}
+	b.history = append(b.history, sarufi.ConversationHistory{
+		ID:           len(b.history) + 1,
+		Message:      message,
+		Sender:       "user",
+		Response:     []sarufi.Response{{Message: conversation.Message}},
+		ReceivedTime: time.Now().Format(time.RFC3339),
+	})
+	return conversation, nil
+}
+
+func (b *offlineBackend) State(ctx context.Context) (sarufi.Conversation, error) {
+	chatID := b.ChatID()
+	current, next := b.engine.State(chatID)
+	return sarufi.Conversation{
+		Memory:       b.engine.Memory(chatID),
+		CurrentState: current,
+		NextState:    next,
+	}, nil
+}
+
+func (b *offlineBackend) Predict(ctx context.Context, message string) (sarufi.Prediction, error) {
+	return b.classifier.PredictIntent(ctx, message)
+}
+
+func (b *offlineBackend) History(ctx context.Context) ([]sarufi.ConversationHistory, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]sarufi.ConversationHistory(nil), b.history...), nil
+}
+
+func (b *offlineBackend) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.engine.Reset(b.chatID)
+	b.chatID = uuid.New().String()
+	b.history = nil
+}
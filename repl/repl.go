@@ -0,0 +1,299 @@
+// Package repl is an interactive chat with a Sarufi bot in the
+// terminal. It reads messages line by line, prints the replies of
+// the bot with the state of the chat after each turn, and handles
+// slash commands:
+//
+//	/reset           start a new chat
+//	/state           show the current and next state
+//	/predict <text>  predict the intent of a message
+//	/history         show the conversation history
+//	/memory          show the chat memory
+//	/save <file>     save the transcript as Markdown
+//	/help            list the commands
+//	/quit            leave
+//
+// The bot is either the live API or a local sarufi.Engine, see
+// Live and Offline.
+package repl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// REPL is an interactive chat with a Backend.
+type REPL struct {
+	// Title names the chat in the transcript, e.g. the bot name.
+	Title string
+	// Prompt is printed before reading each line.
+	Prompt string
+
+	backend Backend
+	in      io.Reader
+	out     io.Writer
+	turns   []turn
+}
+
+// turn is an exchange of the transcript.
+type turn struct {
+	message      string
+	reply        []string
+	currentState string
+	nextState    string
+}
+
+// New returns a REPL reading messages from in and writing to out.
+func New(backend Backend, in io.Reader, out io.Writer) *REPL {
+	return &REPL{
+		Title:   "Sarufi chat",
+		Prompt:  "you> ",
+		backend: backend,
+		in:      in,
+		out:     out,
+	}
+}
+
+// Run reads lines until the input ends, /quit is entered or ctx
+// is done. Failed messages and commands are reported and the chat
+// goes on. When ctx is done while waiting for a line, Run returns
+// ctx.Err() at once; the input is still read in the background
+// until its next line.
+func (r *REPL) Run(ctx context.Context) error {
+	fmt.Fprintf(r.out, "Chat %s. Type /help for the commands.\n", r.backend.ChatID())
+
+	lines, errc := r.readLines(ctx)
+	for {
+		fmt.Fprint(r.out, r.Prompt)
+		var line string
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(r.out)
+			return ctx.Err()
+		case l, ok := <-lines:
+			if !ok {
+				fmt.Fprintln(r.out)
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return <-errc
+			}
+			line = l
+		}
+		quit, err := r.Handle(ctx, line)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(r.out, "error: %v\n", err)
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// readLines reads the input line by line in a goroutine, so that
+// Run can stop while a read blocks. The lines channel is closed
+// when the input ends, then the read error is sent on errc.
+func (r *REPL) readLines(ctx context.Context) (<-chan string, <-chan error) {
+	lines := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(lines)
+		scanner := bufio.NewScanner(r.in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		errc <- scanner.Err()
+	}()
+	return lines, errc
+}
+
+// Handle handles one line of input, a message or a command. It
+// reports whether the line asked to quit.
+func (r *REPL) Handle(ctx context.Context, line string) (quit bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false, nil
+	}
+	if !strings.HasPrefix(line, "/") {
+		return false, r.send(ctx, line)
+	}
+
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+	switch name {
+	case "/quit", "/exit":
+		return true, nil
+	case "/help":
+		r.help()
+		return false, nil
+	case "/reset":
+		r.backend.Reset()
+		r.turns = nil
+		fmt.Fprintf(r.out, "New chat %s\n", r.backend.ChatID())
+		return false, nil
+	case "/state":
+		return false, r.state(ctx)
+	case "/predict":
+		if arg == "" {
+			return false, fmt.Errorf("usage: /predict <text>")
+		}
+		return false, r.predict(ctx, arg)
+	case "/history":
+		return false, r.history(ctx)
+	case "/memory":
+		return false, r.memory(ctx)
+	case "/save":
+		if arg == "" {
+			return false, fmt.Errorf("usage: /save <file>")
+		}
+		return false, r.save(arg)
+	}
+	return false, fmt.Errorf("unknown command %s, type /help for the commands", name)
+}
+
+func (r *REPL) send(ctx context.Context, message string) error {
+	conversation, err := r.backend.Send(ctx, message)
+	if err != nil {
+		return err
+	}
+	for _, m := range conversation.Message {
+		fmt.Fprintf(r.out, "bot> %s\n", m)
+	}
+	fmt.Fprintf(r.out, "     [%s -> %s]\n", orNone(conversation.CurrentState), orNone(conversation.NextState))
+
+	r.turns = append(r.turns, turn{
+		message:      message,
+		reply:        conversation.Message,
+		currentState: conversation.CurrentState,
+		nextState:    conversation.NextState,
+	})
+	return nil
+}
+
+func (r *REPL) help() {
+	fmt.Fprint(r.out, `Commands:
+  /reset           start a new chat
+  /state           show the current and next state
+  /predict <text>  predict the intent of a message
+  /history         show the conversation history
+  /memory          show the chat memory
+  /save <file>     save the transcript as Markdown
+  /quit            leave
+`)
+}
+
+func (r *REPL) state(ctx context.Context) error {
+	state, err := r.backend.State(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "current state: %s\nnext state:    %s\n", orNone(state.CurrentState), orNone(state.NextState))
+	return nil
+}
+
+func (r *REPL) predict(ctx context.Context, text string) error {
+	prediction, err := r.backend.Predict(ctx, text)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "intent: %s (confidence %.2f)\n", orNone(prediction.Intent), prediction.Confidence)
+	return nil
+}
+
+func (r *REPL) history(ctx context.Context) error {
+	history, err := r.backend.History(ctx)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		fmt.Fprintln(r.out, "no history")
+		return nil
+	}
+	for _, h := range history {
+		fmt.Fprintf(r.out, "%s  %s: %s\n", h.ReceivedTime, h.Sender, h.Message)
+		for _, response := range h.Response {
+			for _, m := range response.Message {
+				fmt.Fprintf(r.out, "%s  bot: %s\n", h.ReceivedTime, m)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *REPL) memory(ctx context.Context) error {
+	state, err := r.backend.State(ctx)
+	if err != nil {
+		return err
+	}
+	memory, _ := state.Memory.(map[string]interface{})
+	if len(memory) == 0 {
+		fmt.Fprintln(r.out, "memory is empty")
+		return nil
+	}
+	keys := make([]string, 0, len(memory))
+	for key := range memory {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, err := json.Marshal(memory[key])
+		if err != nil {
+			value = []byte(fmt.Sprint(memory[key]))
+		}
+		fmt.Fprintf(r.out, "%s = %s\n", key, value)
+	}
+	return nil
+}
+
+func (r *REPL) save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.WriteTranscript(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "saved %d turns to %s\n", len(r.turns), path)
+	return nil
+}
+
+// WriteTranscript writes the turns of the current chat as Markdown.
+func (r *REPL) WriteTranscript(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\nChat ID: `%s`\n", r.Title, r.backend.ChatID())
+	for _, t := range r.turns {
+		fmt.Fprintf(&b, "\n**You:** %s\n\n", t.message)
+		for _, m := range t.reply {
+			fmt.Fprintf(&b, "**Bot:** %s\n\n", m)
+		}
+		fmt.Fprintf(&b, "_%s -> %s_\n", orNone(t.currentState), orNone(t.nextState))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
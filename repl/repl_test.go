@@ -0,0 +1,144 @@
+package repl_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/repl"
+)
+
+// fakeBackend replies with the message it got and remembers the
+// last one in its memory.
+type fakeBackend struct {
+	chatID  string
+	resets  int
+	last    string
+	history []sarufi.ConversationHistory
+	err     error
+}
+
+func (b *fakeBackend) ChatID() string { return b.chatID }
+
+func (b *fakeBackend) Send(ctx context.Context, message string) (sarufi.Conversation, error) {
+	if b.err != nil {
+		return sarufi.Conversation{}, b.err
+	}
+	b.last = message
+	b.history = append(b.history, sarufi.ConversationHistory{
+		Message:      message,
+		Sender:       "user",
+		Response:     []sarufi.Response{{Message: []string{"echo " + message}}},
+		ReceivedTime: "t",
+	})
+	return sarufi.Conversation{Message: []string{"echo " + message}, CurrentState: "start", NextState: "end"}, nil
+}
+
+func (b *fakeBackend) State(ctx context.Context) (sarufi.Conversation, error) {
+	var memory map[string]interface{}
+	if b.last != "" {
+		memory = map[string]interface{}{"last": b.last, "count": len(b.history)}
+	}
+	return sarufi.Conversation{Memory: memory, NextState: "end"}, nil
+}
+
+func (b *fakeBackend) Predict(ctx context.Context, message string) (sarufi.Prediction, error) {
+	return sarufi.Prediction{Intent: "greets", Confidence: 0.5}, nil
+}
+
+func (b *fakeBackend) History(ctx context.Context) ([]sarufi.ConversationHistory, error) {
+	return b.history, nil
+}
+
+func (b *fakeBackend) Reset() {
+	b.resets++
+	b.chatID = "chat-2"
+	b.last = ""
+	b.history = nil
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+		want  []string
+		not   []string
+	}{
+		{"quit", "/quit\nhello\n", nil, nil, []string{"echo hello"}},
+		{"exit", "/exit\nhello\n", nil, nil, []string{"echo hello"}},
+		{"end of input", "hello\n", nil, []string{"bot> echo hello", "[start -> end]"}, nil},
+		{"blank lines", "\n  \nhi\n", nil, []string{"bot> echo hi"}, nil},
+		{"help", "/help\n", nil, []string{"/reset", "/save <file>", "/quit"}, nil},
+		{"history", "hi\n/history\n", nil, []string{"t  user: hi", "t  bot: echo hi"}, nil},
+		{"empty history", "/history\n", nil, []string{"no history"}, nil},
+		{"memory", "hi\n/memory\n", nil, []string{"count = 1\nlast = \"hi\""}, nil},
+		{"empty memory", "/memory\n", nil, []string{"memory is empty"}, nil},
+		{"reset", "hi\n/reset\n/memory\n", nil, []string{"New chat chat-2", "memory is empty"}, nil},
+		{"predict", "/predict hi\n", nil, []string{"intent: greets (confidence 0.50)"}, nil},
+		{"unknown command", "/launch\nhi\n", nil, []string{"error: unknown command /launch", "bot> echo hi"}, nil},
+		{"missing argument", "/predict\n/save\n", nil, []string{"error: usage: /predict <text>", "error: usage: /save <file>"}, nil},
+		{"failed message", "hi\n", errors.New("server down"), []string{"error: server down"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{chatID: "chat-1", err: tt.err}
+			var out strings.Builder
+			r := repl.New(backend, strings.NewReader(tt.input), &out)
+			if err := r.Run(context.Background()); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output does not contain %q:\n%s", want, out.String())
+				}
+			}
+			for _, not := range tt.not {
+				if strings.Contains(out.String(), not) {
+					t.Errorf("output contains %q:\n%s", not, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	// The pipe is never written, so Run waits for a line.
+	in, w := io.Pipe()
+	defer w.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- repl.New(&fakeBackend{chatID: "chat"}, in, io.Discard).Run(ctx)
+	}()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run returned %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
+
+func TestWriteTranscript(t *testing.T) {
+	r := repl.New(&fakeBackend{chatID: "chat-1"}, strings.NewReader("hi\n"), io.Discard)
+	r.Title = "Pizza"
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := r.WriteTranscript(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Pizza\n\nChat ID: `chat-1`\n\n**You:** hi\n\n**Bot:** echo hi\n\n_start -> end_\n"
+	if b.String() != want {
+		t.Errorf("transcript %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,150 @@
+package sarufi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides when and how often a failed request is
+// sent again. A request is retried when it failed with one of
+// RetryableStatus, or with a network error, and its method is in
+// RetryableMethods. POST requests are not retried by default so
+// CreateBot and Respond are never duplicated; read-only POSTs such
+// as Predict and ChatState are marked idempotent and retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including
+	// the first one. A value of 1 or less disables retries.
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry. It doubles
+	// on every further retry up to MaxBackoff.
+	BaseBackoff time.Duration
+	// MaxBackoff is the longest wait between two attempts, zero
+	// for no limit. When the server asks with Retry-After for a
+	// longer one, the request is not retried and its error is
+	// returned at once.
+	MaxBackoff time.Duration
+	// Jitter is the fraction (0 to 1) of each wait that is
+	// randomized, so clients do not retry in lockstep.
+	Jitter           float64
+	RetryableStatus  []int
+	RetryableMethods []string
+}
+
+// DefaultRetryPolicy returns the policy used by clients created
+// without WithRetryPolicy: three attempts on 429, 500, 502, 503
+// and 504 for idempotent methods.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: 200 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		Jitter:      0.2,
+		RetryableStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableMethods: []string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE"},
+	}
+}
+
+// WithRetryPolicy sets the retry policy of the client. Use
+// RetryPolicy{MaxAttempts: 1} to disable retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// retryable reports whether a request with the given method may
+// be sent again.
+func (p RetryPolicy) retryable(method string, idempotent bool) bool {
+	if idempotent {
+		return true
+	}
+	for _, m := range p.RetryableMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableStatus reports whether the status code is worth a retry.
+func (p RetryPolicy) retryableStatus(statusCode int) bool {
+	for _, code := range p.RetryableStatus {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableError reports whether a transport error is worth a
+// retry. Errors caused by the caller's context are not.
+func retryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// backoff returns how long to wait before the given retry,
+// starting at 1 for the first one.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := p.BaseBackoff
+	for i := 1; i < retry && wait < math.MaxInt64/2; i++ {
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			break
+		}
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if p.Jitter > 0 && wait > 0 {
+		wait -= time.Duration(rand.Float64() * p.Jitter * float64(wait))
+	}
+	return wait
+}
+
+// retryAfter parses the Retry-After header, given either in
+// seconds or as an HTTP date. It returns 0 if there is none.
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done. If the context deadline
+// would pass before d elapses, it gives up straight away.
+func sleep(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.DeadlineExceeded
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,87 @@
+package sarufi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		maxBackoff time.Duration
+		attempts   int32
+		wantErr    bool
+	}{
+		{"honored when short", "1", 2 * time.Second, 2, false},
+		{"gives up when longer than MaxBackoff", "3", 100 * time.Millisecond, 1, true},
+		{"honored without MaxBackoff", "1", 0, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(RetryPolicy{
+				MaxAttempts:      3,
+				BaseBackoff:      time.Millisecond,
+				MaxBackoff:       tt.maxBackoff,
+				RetryableStatus:  []int{http.StatusServiceUnavailable},
+				RetryableMethods: []string{"GET"},
+			}))
+			start := time.Now()
+			_, err := client.makeRequest(context.Background(), "GET", client.url("chatbots"), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && StatusCode(err) != http.StatusServiceUnavailable {
+				t.Errorf("err = %v, want the 503 APIError", err)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.attempts {
+				t.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxBackoff time.Duration
+		want       []time.Duration
+	}{
+		{"capped", 300 * time.Millisecond, []time.Duration{100, 200, 300, 300}},
+		{"no cap", 0, []time.Duration{100, 200, 400, 800, 1600}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: tt.maxBackoff}
+			for i, want := range tt.want {
+				if got := policy.backoff(i + 1); got != want*time.Millisecond {
+					t.Errorf("retry %d: backoff %v, want %v", i+1, got, want*time.Millisecond)
+				}
+			}
+		})
+	}
+
+	// Many retries without a cap do not overflow.
+	policy := RetryPolicy{BaseBackoff: time.Second}
+	if got := policy.backoff(100); got <= 0 {
+		t.Errorf("retry 100: backoff %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package sarufi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -10,90 +11,58 @@ import (
 // It accepts the bot id (type int) as a parameter.
 // Returns a pointer of type Bot and an error.
 func (app *Application) GetBot(id int) (*Bot, error) {
-	if !checkToken(token) {
+	return app.GetBotContext(context.Background(), id)
+}
+
+// GetBotContext is like GetBot but uses ctx for the request.
+func (app *Application) GetBotContext(ctx context.Context, id int) (*Bot, error) {
+	client := app.Client()
+	if !checkToken(client.key()) {
 		return nil, fmt.Errorf("Error: No token available")
 	}
-	url := fmt.Sprintf("%schatbot/%d", baseURL, id)
+	url := client.url(fmt.Sprintf("chatbot/%d", id))
 
-	statusCode, body, err := makeRequest("GET", url, nil)
+	body, err := client.makeRequest(ctx, "GET", url, nil, forBot(id))
 	if err != nil {
 		return nil, err
 	}
 
-	switch statusCode {
-	case 200:
-		var bot *Bot
-		if err := json.Unmarshal(body, &bot); err != nil {
-			return nil, err
-		}
-		return bot, nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return nil, fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return nil, fmt.Errorf(string(body))
+	var bot Bot
+	if err := json.Unmarshal(body, &bot); err != nil {
+		return nil, err
 	}
+	bot.client = client
+	bot.version = fingerprint(&bot)
+	return &bot, nil
 }
 
 // GetBots() method returns a list of type Bot and an error
 func (app *Application) GetAllBots() ([]Bot, error) {
-	if !checkToken(token) {
+	return app.GetAllBotsContext(context.Background())
+}
+
+// GetAllBotsContext is like GetAllBots but uses ctx for the request.
+func (app *Application) GetAllBotsContext(ctx context.Context) ([]Bot, error) {
+	client := app.Client()
+	if !checkToken(client.key()) {
 		return nil, fmt.Errorf("Error: No token available")
 	}
 
-	url := baseURL + "chatbots"
+	url := client.url("chatbots")
 
-	statusCode, body, err := makeRequest("GET", url, nil)
+	body, err := client.makeRequest(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
-	switch statusCode {
-	case 200:
-		var bots []Bot
-		if err := json.Unmarshal(body, &bots); err != nil {
-			return nil, err
-		}
-		return bots, nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return nil, fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return nil, fmt.Errorf(string(body))
+	var bots []Bot
+	if err := json.Unmarshal(body, &bots); err != nil {
+		return nil, err
+	}
+	for i := range bots {
+		bots[i].client = client
+		bots[i].version = fingerprint(&bots[i])
 	}
+	return bots, nil
 
 }
 
@@ -104,11 +73,17 @@ func (app *Application) GetAllBots() ([]Bot, error) {
 // Visible (type bool) - allow it to be publicly available
 // It returns a pointer to a new Bot and an error
 func (app *Application) CreateBot(name, description, industry string, visible bool) (*Bot, error) {
-	if !checkToken(token) {
+	return app.CreateBotContext(context.Background(), name, description, industry, visible)
+}
+
+// CreateBotContext is like CreateBot but uses ctx for the request.
+func (app *Application) CreateBotContext(ctx context.Context, name, description, industry string, visible bool) (*Bot, error) {
+	client := app.Client()
+	if !checkToken(client.key()) {
 		return nil, fmt.Errorf("Error: No token available")
 	}
 
-	url := baseURL + "chatbot"
+	url := client.url("chatbot")
 
 	params := map[string]interface{}{
 		"name":                 name,
@@ -122,177 +97,92 @@ func (app *Application) CreateBot(name, description, industry string, visible bo
 		return nil, err
 	}
 
-	statusCode, body, err := makeRequest("POST", url, bytes.NewBuffer(jsonParams))
+	body, err := client.makeRequest(ctx, "POST", url, bytes.NewBuffer(jsonParams))
 
 	if err != nil {
 		return nil, err
 	}
 
-	switch statusCode {
-	case 200:
-		var bot *Bot
-		if err := json.Unmarshal(body, &bot); err != nil {
-			return nil, err
-		}
-		return bot, nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return nil, fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return nil, fmt.Errorf(string(body))
+	var bot Bot
+	if err := json.Unmarshal(body, &bot); err != nil {
+		return nil, err
 	}
+	bot.client = client
+	bot.version = fingerprint(&bot)
+	return &bot, nil
 }
 
 // UpdateBot() method to update the bot. It accepts a parameter of
-// type *Bot and will return  an error if any
+// type *Bot and will return  an error if any. Only the definition
+// of the bot is sent, not runtime fields such as Conversation.
+// If the bot changed on the server since it was fetched with
+// GetBot or GetAllBots, or created, the update is refused with
+// ErrStaleBot. Bots built by hand have no such check.
+// Use UpdateBotFields to change only some fields.
 func (app *Application) UpdateBot(bot *Bot) error {
-	if !checkToken(token) {
-		return fmt.Errorf("Error: No token available")
-	}
-
-	jsonParams, err := json.Marshal(bot)
+	return app.UpdateBotContext(context.Background(), bot)
+}
 
-	if err != nil {
-		return err
-	}
-	url := fmt.Sprintf("%schatbot/%d", baseURL, bot.Id)
-	statusCode, body, err := makeRequest("PUT", url, bytes.NewBuffer(jsonParams))
+// UpdateBotContext is like UpdateBot but uses ctx for the request.
+func (app *Application) UpdateBotContext(ctx context.Context, bot *Bot) error {
+	body, version, err := app.updateBot(ctx, bot.Id, bot, definitionOf(bot))
 	if err != nil {
 		return err
 	}
 
-	switch statusCode {
-	case 200:
-		if err := json.Unmarshal(body, &bot); err != nil {
-			return err
-		}
-		return nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return fmt.Errorf(string(body))
+	if err := json.Unmarshal(body, bot); err != nil {
+		return err
 	}
-
+	bot.client = app.Client()
+	bot.version = version
+	return nil
 }
 
 // DeleteBot() will delete the bot of with provided ID.
 // It will return an error if deletion was unsuccessful
 func (app *Application) DeleteBot(id int) error {
-	if !checkToken(token) {
+	return app.DeleteBotContext(context.Background(), id)
+}
+
+// DeleteBotContext is like DeleteBot but uses ctx for the request.
+func (app *Application) DeleteBotContext(ctx context.Context, id int) error {
+	client := app.Client()
+	if !checkToken(client.key()) {
 		return fmt.Errorf("Error: No token available")
 	}
 
-	url := fmt.Sprintf("%schatbot/%d", baseURL, id)
-	statusCode, body, err := makeRequest("DELETE", url, nil)
+	url := client.url(fmt.Sprintf("chatbot/%d", id))
+	_, err := client.makeRequest(ctx, "DELETE", url, nil, forBot(id))
 	if err != nil {
 		return err
 	}
 
-	switch statusCode {
-	case 200:
-		return nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return err
-		}
-		return fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return fmt.Errorf(string(body))
-	}
+	return nil
 }
 
 // Get user's information
 func (app *Application) GetUser() (*User, error) {
-	if !checkToken(token) {
+	return app.GetUserContext(context.Background())
+}
+
+// GetUserContext is like GetUser but uses ctx for the request.
+func (app *Application) GetUserContext(ctx context.Context) (*User, error) {
+	client := app.Client()
+	if !checkToken(client.key()) {
 		return nil, fmt.Errorf("Error: No token available")
 	}
 
-	url := fmt.Sprintf("%sapi/profile", baseURL)
-	statusCode, body, err := makeRequest("GET", url, nil)
+	url := client.url("api/profile")
+	body, err := client.makeRequest(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	switch statusCode {
-	case 200:
-		if err := json.Unmarshal(body, &app.User); err != nil {
-			return nil, err
-		}
-		var user *User
-		user = &app.User
-
-		return user, nil
-	case 401:
-		var unauthorized Unauthorized
-		if err := json.Unmarshal(body, &unauthorized); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", unauthorized.Error())
-	case 404:
-		var notFound NotFoundError
-		if err := json.Unmarshal(body, &notFound); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", notFound.Error())
-	case 422:
-		var unprocessableEntity *UnprocessableEntity
-		if err := json.Unmarshal(body, &unprocessableEntity); err != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("Error %s", unprocessableEntity.Error())
-	case 500:
-		return nil, fmt.Errorf("Error status code 500: Internal Server Error")
-	default:
-		return nil, fmt.Errorf(string(body))
+	if err := json.Unmarshal(body, &app.User); err != nil {
+		return nil, err
 	}
+	var user *User
+	user = &app.User
+
+	return user, nil
 }
@@ -0,0 +1,43 @@
+package sarufi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNullBotBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("null"))
+	}))
+	defer server.Close()
+	app := NewApplication(WithAPIKey("key"), WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() (*Bot, error)
+	}{
+		{"GetBot", func() (*Bot, error) { return app.GetBotContext(ctx, 1) }},
+		{"CreateBot", func() (*Bot, error) { return app.CreateBotContext(ctx, "Pizza", "", "", false) }},
+		{"UpdateBotFields", func() (*Bot, error) {
+			return app.UpdateBotFields(ctx, 1, BotUpdate{Name: StringField("Pizza")})
+		}},
+		{"UpdateBot", func() (*Bot, error) {
+			bot := &Bot{Id: 1, Name: "Pizza"}
+			return bot, app.UpdateBotContext(ctx, bot)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, err := tt.call()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bot == nil || bot.client == nil {
+				t.Errorf("bot %+v has no client", bot)
+			}
+		})
+	}
+}
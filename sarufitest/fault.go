@@ -0,0 +1,102 @@
+package sarufitest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault is an injected response. The server waits for Delay, then
+// answers with StatusCode, Header and Body instead of handling the
+// request. A Fault with a zero StatusCode only delays the request,
+// which is then handled as usual.
+type Fault struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+	Delay      time.Duration
+}
+
+// FaultFunc decides whether a request fails. It returns nil to let
+// the request through.
+type FaultFunc func(r *http.Request) *Fault
+
+// AddFault registers a fault hook. Hooks run in the order they were
+// added, before authentication, and the first non nil Fault wins.
+func (s *Server) AddFault(fault FaultFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, fault)
+}
+
+// ClearFaults removes every fault hook.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// FailTimes fails the first n requests with the given status code,
+// then lets requests through. It is safe for concurrent use.
+func FailTimes(n, statusCode int) FaultFunc {
+	var mu sync.Mutex
+	return func(r *http.Request) *Fault {
+		mu.Lock()
+		defer mu.Unlock()
+		if n <= 0 {
+			return nil
+		}
+		n--
+		return &Fault{StatusCode: statusCode}
+	}
+}
+
+// FailPath fails every request whose path starts with prefix,
+// e.g. "/conversation", with the given fault.
+func FailPath(prefix string, fault Fault) FaultFunc {
+	return func(r *http.Request) *Fault {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			f := fault
+			return &f
+		}
+		return nil
+	}
+}
+
+// wait sleeps for the fault delay. It returns false if the
+// client went away in the meantime.
+func (f *Fault) wait(ctx context.Context) bool {
+	if f.Delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(f.Delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// write sends the fault response.
+func (f *Fault) write(w http.ResponseWriter) {
+
+	for key, values := range f.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	statusCode := f.StatusCode
+	body := f.Body
+	if body == "" {
+		body = `{"detail":"` + http.StatusText(statusCode) + `"}`
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(statusCode)
+	w.Write([]byte(body))
+}
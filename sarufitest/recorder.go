@@ -0,0 +1,318 @@
+package sarufitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode selects what a Recorder does with requests.
+type Mode int
+
+const (
+	// ModeRecord sends requests to the real server and records
+	// the exchanges. Save writes them to the cassette file.
+	ModeRecord Mode = iota
+	// ModeReplay answers requests from the cassette file and fails
+	// requests that were not recorded, without any network access.
+	ModeReplay
+	// ModePassthrough sends requests to the real server and
+	// records nothing.
+	ModePassthrough
+)
+
+// ErrNoInteraction is returned in ModeReplay for requests that
+// match no unused recorded interaction.
+var ErrNoInteraction = errors.New("sarufitest: no recorded interaction")
+
+// chatPlaceholder prefixes the stand-ins for chat IDs in cassettes.
+const chatPlaceholder = "redacted-chat-"
+
+// Cassette is the golden file format written by a Recorder.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request with the bearer token dropped and
+// chat IDs replaced by placeholders.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response with chat IDs replaced by
+// placeholders.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that records exchanges with the
+// Sarufi API into a cassette file and replays them, so integration
+// tests run deterministically in CI. Use it with the SDK as:
+//
+//	rec, err := sarufitest.NewRecorder("testdata/respond.json", sarufitest.ModeReplay)
+//	app := sarufi.NewApplication(sarufi.WithAPIKey(key), sarufi.WithHTTPClient(rec.HTTPClient()))
+//
+// Requests match a recorded interaction on method, path and JSON
+// body, compared after normalization. Interactions are used once,
+// in order. The Authorization header is never recorded and chat IDs
+// are replaced by placeholders, both in bodies and in paths.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+	chatIDs  map[string]string
+}
+
+// NewRecorder returns a Recorder for the cassette at path. In
+// ModeReplay the cassette must exist. Requests reaching the real
+// server go through http.DefaultTransport.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		chatIDs:   make(map[string]string),
+	}
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("sarufitest: reading cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// SetTransport sets the transport used to reach the real server.
+func (r *Recorder) SetTransport(transport http.RoundTripper) {
+	r.transport = transport
+}
+
+// HTTPClient returns an http.Client using the Recorder, to pass
+// to sarufi.WithHTTPClient.
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Save writes the recorded interactions to the cassette file. It
+// does nothing outside of ModeRecord.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModePassthrough {
+		return r.transport.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	r.mu.Lock()
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   r.redactPath(req.URL.Path),
+		Body:   r.redactBody(body),
+	}
+	r.mu.Unlock()
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := http.Header{}
+	for _, key := range []string{"Content-Type", "Retry-After", "X-Request-Id"} {
+		if value := resp.Header.Get(key); value != "" {
+			header.Set(key, value)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       r.redactBody(body),
+		},
+	})
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Request != recorded {
+			continue
+		}
+		r.used[i] = true
+		body := r.restoreBody(interaction.Response.Body)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w for %s %s %s", ErrNoInteraction, recorded.Method, recorded.Path, recorded.Body)
+}
+
+// Unused returns the recorded interactions replay has not used,
+// to check that a test made every expected request. It returns nil
+// outside of ModeReplay.
+func (r *Recorder) Unused() []Interaction {
+	if r.mode != ModeReplay {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, interaction := range r.cassette.Interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// placeholder returns the stable stand-in of a chat ID. Callers
+// hold r.mu.
+func (r *Recorder) placeholder(chatID string) string {
+	if strings.HasPrefix(chatID, chatPlaceholder) {
+		return chatID
+	}
+	p, ok := r.chatIDs[chatID]
+	if !ok {
+		p = fmt.Sprintf("%s%d", chatPlaceholder, len(r.chatIDs)+1)
+		r.chatIDs[chatID] = p
+	}
+	return p
+}
+
+// redactPath replaces the chat ID of history paths, which look
+// like /conversation/history/{bot}/{chat}.
+func (r *Recorder) redactPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i := 0; i+3 < len(parts); i++ {
+		if parts[i] == "conversation" && parts[i+1] == "history" {
+			parts[i+3] = r.placeholder(parts[i+3])
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// redactBody normalizes a JSON body and replaces every chat_id
+// value. Bodies that are not JSON are kept as they are.
+func (r *Recorder) redactBody(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return string(body)
+	}
+	value = r.redactValue(value)
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return string(body)
+	}
+	return string(normalized)
+}
+
+func (r *Recorder) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if chatID, ok := field.(string); ok && key == "chat_id" {
+				v[key] = r.placeholder(chatID)
+				continue
+			}
+			v[key] = r.redactValue(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+	}
+	return value
+}
+
+// restoreBody puts the chat IDs seen during replay back in place
+// of their placeholders. Callers hold r.mu.
+func (r *Recorder) restoreBody(body string) string {
+	for chatID, p := range r.chatIDs {
+		quoted, err := json.Marshal(chatID)
+		if err != nil {
+			continue
+		}
+		body = strings.ReplaceAll(body, `"`+p+`"`, string(quoted))
+	}
+	return body
+}
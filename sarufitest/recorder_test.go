@@ -0,0 +1,109 @@
+package sarufitest_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sarufi "github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/sarufitest"
+)
+
+// converse runs a short chat through app and returns the replies,
+// the chat history and the chat users of the bot.
+func converse(t *testing.T, app *sarufi.Application, botID int, chatID string) ([][]string, []sarufi.ConversationHistory, []sarufi.ChatUser) {
+	t.Helper()
+	ctx := context.Background()
+	bot, err := app.GetBotContext(ctx, botID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := bot.Session(chatID)
+	var replies [][]string
+	for _, message := range []string{"I want pizza", "2"} {
+		reply, err := session.Send(ctx, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replies = append(replies, reply.Message)
+	}
+	history, err := session.History(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := bot.ListChatUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return replies, history, users
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "testdata", "pizza.json")
+
+	srv := sarufitest.NewServer()
+	botID := srv.AddBot(pizzaBot())
+	rec, err := sarufitest.NewRecorder(cassette, sarufitest.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := sarufi.NewApplication(
+		sarufi.WithAPIKey(sarufitest.DefaultAPIKey),
+		sarufi.WithBaseURL(srv.URL),
+		sarufi.WithHTTPClient(rec.HTTPClient()),
+	)
+	recordedReplies, _, _ := converse(t, app, botID, "recorded-chat")
+	if unused := rec.Unused(); unused != nil {
+		t.Errorf("Unused() = %v while recording", unused)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "recorded-chat") || strings.Contains(string(data), sarufitest.DefaultAPIKey) {
+		t.Errorf("cassette holds the chat ID or the API key:\n%s", data)
+	}
+
+	// Replay with another chat ID and no server at all.
+	rec, err = sarufitest.NewRecorder(cassette, sarufitest.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app = sarufi.NewApplication(
+		sarufi.WithAPIKey("another-key"),
+		sarufi.WithBaseURL(srv.URL),
+		sarufi.WithHTTPClient(rec.HTTPClient()),
+	)
+	replies, history, users := converse(t, app, botID, "replayed-chat")
+	if len(replies) != len(recordedReplies) {
+		t.Fatalf("replayed %d replies, recorded %d", len(replies), len(recordedReplies))
+	}
+	for i := range replies {
+		if strings.Join(replies[i], "\n") != strings.Join(recordedReplies[i], "\n") {
+			t.Errorf("reply %d: %q, recorded %q", i, replies[i], recordedReplies[i])
+		}
+	}
+	if len(history) != 2 {
+		t.Errorf("history has %d entries, want 2", len(history))
+	}
+	if len(users) != 1 || users[0].ChatID != "replayed-chat" {
+		t.Errorf("chat users %+v, want the placeholder restored to replayed-chat", users)
+	}
+	if unused := rec.Unused(); len(unused) != 0 {
+		t.Errorf("%d interactions left unused", len(unused))
+	}
+
+	ctx := context.Background()
+	bot, err := app.GetBotContext(ctx, botID)
+	if !errors.Is(err, sarufitest.ErrNoInteraction) {
+		t.Errorf("unrecorded request: bot %v, err %v, want ErrNoInteraction", bot, err)
+	}
+}
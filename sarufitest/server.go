@@ -0,0 +1,567 @@
+// Package sarufitest provides an in-memory fake of the Sarufi API
+// for hermetic tests of code using the sarufi package.
+//
+//	srv := sarufitest.NewServer()
+//	defer srv.Close()
+//
+//	app := srv.Application()
+//	bot, err := app.CreateBot("Pizza", "Orders pizza", "Food", false)
+//
+// Bots are kept in memory, conversations run through sarufi.Engine,
+// every request is recorded and faults can be injected with AddFault.
+package sarufitest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sarufi "github.com/sarufi-io/sarufi-golang-sdk"
+)
+
+// DefaultAPIKey is the API key accepted by a Server created
+// without WithAPIKey.
+const DefaultAPIKey = "sarufitest-api-key"
+
+// Server is a fake Sarufi API listening on a local address.
+type Server struct {
+	// URL is the base URL of the server, to use with sarufi.WithBaseURL.
+	URL string
+
+	srv    *httptest.Server
+	apiKey string
+	user   sarufi.User
+
+	mu       sync.Mutex
+	nextID   int
+	bots     map[int]*sarufi.Bot
+	engines  map[int]*sarufi.Engine
+	chats    map[int]map[string]*chat
+	requests []Request
+	faults   []FaultFunc
+}
+
+// Request is a request received by the Server.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+	Time   time.Time
+}
+
+// chat is the recorded history of a single chat.
+type chat struct {
+	started time.Time
+	history []sarufi.ConversationHistory
+}
+
+// Option configures a Server. See NewServer.
+type Option func(*Server)
+
+// WithAPIKey sets the only API key the server accepts.
+func WithAPIKey(apiKey string) Option {
+	return func(s *Server) {
+		s.apiKey = apiKey
+	}
+}
+
+// WithUser sets the profile returned by api/profile.
+func WithUser(user sarufi.User) Option {
+	return func(s *Server) {
+		s.user = user
+	}
+}
+
+// NewServer starts a fake Sarufi API. Call Close when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		apiKey: DefaultAPIKey,
+		user: sarufi.User{
+			ID:       1,
+			FullName: "Test User",
+			Username: "test",
+		},
+		nextID:  1,
+		bots:    make(map[int]*sarufi.Bot),
+		engines: make(map[int]*sarufi.Engine),
+		chats:   make(map[int]map[string]*chat),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL + "/"
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Application returns an application talking to the server with
+// the accepted API key. Extra options are applied last.
+func (s *Server) Application(opts ...sarufi.Option) *sarufi.Application {
+	return sarufi.NewApplication(append([]sarufi.Option{
+		sarufi.WithAPIKey(s.apiKey),
+		sarufi.WithBaseURL(s.URL),
+		sarufi.WithHTTPClient(s.srv.Client()),
+	}, opts...)...)
+}
+
+// AddBot stores a bot as if it had been created through the API
+// and returns its ID. A zero bot ID is replaced by a new one.
+func (s *Server) AddBot(bot sarufi.Bot) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bot.Id == 0 {
+		bot.Id = s.nextID
+	}
+	if bot.Id >= s.nextID {
+		s.nextID = bot.Id + 1
+	}
+	bot.UserID = s.user.ID
+	s.storeBot(&bot)
+	return bot.Id
+}
+
+// Bot returns a copy of the stored bot with the given ID.
+func (s *Server) Bot(id int) (sarufi.Bot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bot, ok := s.bots[id]
+	if !ok {
+		return sarufi.Bot{}, false
+	}
+	return *bot, true
+}
+
+// Requests returns the requests received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Reset removes all bots, chats, recorded requests and faults.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID = 1
+	s.bots = make(map[int]*sarufi.Bot)
+	s.engines = make(map[int]*sarufi.Engine)
+	s.chats = make(map[int]map[string]*chat)
+	s.requests = nil
+	s.faults = nil
+}
+
+// storeBot saves the bot and loads it into its conversation engine,
+// keeping the state of its chats. Callers hold s.mu.
+func (s *Server) storeBot(bot *sarufi.Bot) {
+	if bot.Intents == nil {
+		bot.Intents = map[string][]string{}
+	}
+	if bot.Flows == nil {
+		bot.Flows = sarufi.Flows{}
+	}
+	if bot.WebhookTriggerIntents == nil {
+		bot.WebhookTriggerIntents = []string{}
+	}
+	s.bots[bot.Id] = bot
+	if engine, ok := s.engines[bot.Id]; ok {
+		engine.SetBot(bot)
+		return
+	}
+	s.engines[bot.Id] = sarufi.NewEngine(bot)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, detail(err.Error()))
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+		Time:   time.Now(),
+	})
+	faults := append([]FaultFunc(nil), s.faults...)
+	s.mu.Unlock()
+
+	for _, fault := range faults {
+		f := fault(r)
+		if f == nil {
+			continue
+		}
+		if !f.wait(r.Context()) {
+			return
+		}
+		if f.StatusCode != 0 {
+			f.write(w)
+			return
+		}
+		break
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+		writeJSON(w, http.StatusUnauthorized, detail("Could not validate credentials"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.route(w, r, body)
+}
+
+// route dispatches a request to its handler. Callers hold s.mu.
+func (s *Server) route(w http.ResponseWriter, r *http.Request, body []byte) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/chatbot":
+		s.createBot(w, body)
+	case r.Method == http.MethodGet && r.URL.Path == "/chatbots":
+		s.listBots(w)
+	case len(parts) == 2 && parts[0] == "chatbot":
+		id, ok := s.botID(w, parts[1])
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, botJSON(s.bots[id]))
+		case http.MethodPut:
+			s.updateBot(w, id, body)
+		case http.MethodDelete:
+			s.deleteBot(w, id)
+		default:
+			methodNotAllowed(w)
+		}
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "chatbot" && parts[2] == "users":
+		if id, ok := s.botID(w, parts[1]); ok {
+			s.chatUsers(w, id)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/conversation/":
+		s.conversation(w, body)
+	case r.Method == http.MethodPost && r.URL.Path == "/conversation/status":
+		s.conversationStatus(w, body)
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[0] == "conversation" && parts[1] == "history":
+		if id, ok := s.botID(w, parts[2]); ok {
+			s.history(w, id, parts[3])
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/predict/intent":
+		s.predict(w, body)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/profile":
+		writeJSON(w, http.StatusOK, s.user)
+	default:
+		writeJSON(w, http.StatusNotFound, detail("Not Found"))
+	}
+}
+
+// botID parses and checks a bot ID taken from the path.
+func (s *Server) botID(w http.ResponseWriter, value string) (int, bool) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, validationError("path", "bot_id", "value is not a valid integer", "type_error.integer"))
+		return 0, false
+	}
+	if _, ok := s.bots[id]; !ok {
+		writeJSON(w, http.StatusNotFound, detail("Bot not found"))
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) createBot(w http.ResponseWriter, body []byte) {
+	var params struct {
+		Name               *string `json:"name"`
+		Description        string  `json:"description"`
+		Industry           string  `json:"industry"`
+		VisibleOnCommunity bool    `json:"visible_on_community"`
+	}
+	if err := json.Unmarshal(body, &params); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, validationError("body", "", err.Error(), "value_error.jsondecode"))
+		return
+	}
+	if params.Name == nil || *params.Name == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, validationError("body", "name", "field required", "value_error.missing"))
+		return
+	}
+	for _, bot := range s.bots {
+		if bot.Name == *params.Name {
+			writeJSON(w, http.StatusConflict, map[string]interface{}{
+				"detail": sarufi.Detail{
+					Loc:     []string{"body", "name"},
+					Message: "Bot with this name already exists",
+					Type:    "value_error.conflict",
+				},
+			})
+			return
+		}
+	}
+
+	bot := &sarufi.Bot{
+		Id:                 s.nextID,
+		Name:               *params.Name,
+		Description:        params.Description,
+		Industry:           params.Industry,
+		VisibleOnCommunity: params.VisibleOnCommunity,
+		UserID:             s.user.ID,
+	}
+	s.nextID++
+	s.storeBot(bot)
+	writeJSON(w, http.StatusOK, botJSON(bot))
+}
+
+func (s *Server) listBots(w http.ResponseWriter) {
+	ids := make([]int, 0, len(s.bots))
+	for id := range s.bots {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	bots := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		bots = append(bots, botJSON(s.bots[id]))
+	}
+	writeJSON(w, http.StatusOK, bots)
+}
+
+// updateBot applies the fields present in the body to the bot,
+// leaving the others untouched.
+func (s *Server) updateBot(w http.ResponseWriter, id int, body []byte) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, validationError("body", "", err.Error(), "value_error.jsondecode"))
+		return
+	}
+
+	bot := *s.bots[id]
+	targets := map[string]interface{}{
+		"name":                    &bot.Name,
+		"description":             &bot.Description,
+		"industry":                &bot.Industry,
+		"visible_on_community":    &bot.VisibleOnCommunity,
+		"intents":                 &bot.Intents,
+		"flows":                   &bot.Flows,
+		"model_name":              &bot.ModelName,
+		"webhook_url":             &bot.WebhookURL,
+		"webhook_trigger_intents": &bot.WebhookTriggerIntents,
+	}
+	for key, value := range fields {
+		target, ok := targets[key]
+		if !ok {
+			continue
+		}
+		switch key {
+		case "intents":
+			bot.Intents = nil
+		case "flows":
+			bot.Flows = nil
+		}
+		if err := json.Unmarshal(value, target); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, validationError("body", key, err.Error(), "type_error"))
+			return
+		}
+	}
+	s.storeBot(&bot)
+	writeJSON(w, http.StatusOK, botJSON(&bot))
+}
+
+func (s *Server) deleteBot(w http.ResponseWriter, id int) {
+	delete(s.bots, id)
+	delete(s.engines, id)
+	delete(s.chats, id)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Bot deleted successfully"})
+}
+
+func (s *Server) chatUsers(w http.ResponseWriter, id int) {
+	users := make([]sarufi.ChatUser, 0, len(s.chats[id]))
+	for chatID, c := range s.chats[id] {
+		users = append(users, sarufi.ChatUser{
+			ChatID:       chatID,
+			ReceivedTime: c.started.Format(time.RFC3339),
+		})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ChatID < users[j].ChatID
+	})
+	writeJSON(w, http.StatusOK, users)
+}
+
+// conversationRequest is the body of conversation requests.
+type conversationRequest struct {
+	ChatID  string `json:"chat_id"`
+	BotID   *int   `json:"bot_id"`
+	Message string `json:"message"`
+}
+
+// conversationBot decodes a conversation request and finds its bot.
+func (s *Server) conversationBot(w http.ResponseWriter, body []byte) (conversationRequest, bool) {
+	var params conversationRequest
+	if err := json.Unmarshal(body, &params); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, validationError("body", "", err.Error(), "value_error.jsondecode"))
+		return params, false
+	}
+	if params.BotID == nil {
+		writeJSON(w, http.StatusUnprocessableEntity, validationError("body", "bot_id", "field required", "value_error.missing"))
+		return params, false
+	}
+	if params.ChatID == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, validationError("body", "chat_id", "field required", "value_error.missing"))
+		return params, false
+	}
+	if _, ok := s.bots[*params.BotID]; !ok {
+		writeJSON(w, http.StatusNotFound, detail("Bot not found"))
+		return params, false
+	}
+	return params, true
+}
+
+func (s *Server) conversation(w http.ResponseWriter, body []byte) {
+	params, ok := s.conversationBot(w, body)
+	if !ok {
+		return
+	}
+	id := *params.BotID
+
+	conversation, err := s.engines[id].Respond(params.ChatID, params.Message)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, detail(err.Error()))
+		return
+	}
+
+	if s.chats[id] == nil {
+		s.chats[id] = make(map[string]*chat)
+	}
+	c, ok := s.chats[id][params.ChatID]
+	if !ok {
+		c = &chat{started: time.Now()}
+		s.chats[id][params.ChatID] = c
+	}
+	c.history = append(c.history, sarufi.ConversationHistory{
+		ID:           len(c.history) + 1,
+		Message:      params.Message,
+		Sender:       "user",
+		Response:     []sarufi.Response{{Message: conversation.Message}},
+		ReceivedTime: time.Now().Format(time.RFC3339),
+	})
+
+	writeJSON(w, http.StatusOK, conversation)
+}
+
+func (s *Server) conversationStatus(w http.ResponseWriter, body []byte) {
+	params, ok := s.conversationBot(w, body)
+	if !ok {
+		return
+	}
+	engine := s.engines[*params.BotID]
+	current, next := engine.State(params.ChatID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"current_state": current,
+		"next_state":    next,
+		"memory":        engine.Memory(params.ChatID),
+	})
+}
+
+func (s *Server) history(w http.ResponseWriter, id int, chatID string) {
+	c, ok := s.chats[id][chatID]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, detail("Chat not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"conversation_history": c.history,
+	})
+}
+
+func (s *Server) predict(w http.ResponseWriter, body []byte) {
+	var params struct {
+		Message *string `json:"message"`
+		BotID   *int    `json:"bot_id"`
+	}
+	if err := json.Unmarshal(body, &params); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, validationError("body", "", err.Error(), "value_error.jsondecode"))
+		return
+	}
+	if params.Message == nil {
+		writeJSON(w, http.StatusUnprocessableEntity, validationError("body", "message", "field required", "value_error.missing"))
+		return
+	}
+	if params.BotID == nil {
+		writeJSON(w, http.StatusUnprocessableEntity, validationError("body", "bot_id", "field required", "value_error.missing"))
+		return
+	}
+	bot, ok := s.bots[*params.BotID]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, detail("Bot not found"))
+		return
+	}
+	prediction, err := sarufi.NewLocalClassifier(bot.Intents).PredictIntent(context.Background(), *params.Message)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, detail(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, prediction)
+}
+
+// botJSON returns the fields of a bot the API sends back,
+// leaving out the SDK's runtime fields.
+func botJSON(bot *sarufi.Bot) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                      bot.Id,
+		"name":                    bot.Name,
+		"industry":                bot.Industry,
+		"description":             bot.Description,
+		"user_id":                 bot.UserID,
+		"visible_on_community":    bot.VisibleOnCommunity,
+		"intents":                 bot.Intents,
+		"flows":                   bot.Flows,
+		"model_name":              bot.ModelName,
+		"webhook_url":             bot.WebhookURL,
+		"webhook_trigger_intents": bot.WebhookTriggerIntents,
+		"evaluation_metrics":      bot.EvaluationMetrics,
+	}
+}
+
+func detail(message string) map[string]string {
+	return map[string]string{"detail": message}
+}
+
+func validationError(location, field, message, kind string) map[string]interface{} {
+	loc := []string{location}
+	if field != "" {
+		loc = append(loc, field)
+	}
+	return map[string]interface{}{
+		"detail": []sarufi.Detail{{Loc: loc, Message: message, Type: kind}},
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter) {
+	writeJSON(w, http.StatusMethodNotAllowed, detail("Method Not Allowed"))
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		body = []byte(fmt.Sprintf(`{"detail":%q}`, err.Error()))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
@@ -0,0 +1,114 @@
+package sarufitest_test
+
+import (
+	"context"
+	"testing"
+
+	sarufi "github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/sarufitest"
+)
+
+// pizzaBot is a short version of the README pizza bot.
+func pizzaBot() sarufi.Bot {
+	return sarufi.Bot{
+		Name: "Pizza",
+		Intents: map[string][]string{
+			"greets":      {"hey", "hello", "hi"},
+			"order_pizza": {"I need pizza", "I want pizza"},
+		},
+		Flows: sarufi.Flows{
+			"greets": {State: &sarufi.FlowState{Message: []string{"Hi, How can I help you?"}, NextState: "end"}},
+			"order_pizza": {State: &sarufi.FlowState{
+				Message:   []string{"Sure, How many pizzas would you like to order?"},
+				NextState: "number_of_pizzas",
+			}},
+			"number_of_pizzas": {State: &sarufi.FlowState{
+				Message:   []string{"Cool, Whats your address ?"},
+				NextState: "address",
+			}},
+			"address": {State: &sarufi.FlowState{
+				Message:   []string{"Your order has been placed."},
+				NextState: "end",
+			}},
+		},
+	}
+}
+
+func TestServerKeepsChatsAcrossUpdates(t *testing.T) {
+	ctx := context.Background()
+	srv := sarufitest.NewServer()
+	defer srv.Close()
+	id := srv.AddBot(pizzaBot())
+
+	bot, err := srv.Application().GetBotContext(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := bot.Session("chat")
+	for _, message := range []string{"I want pizza", "2"} {
+		if _, err := session.Send(ctx, message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Another client changes the bot in the middle of the chat.
+	other, err := srv.Application().GetBotContext(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.AddIntent("goodbye", []string{"bye"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Application().UpdateBotContext(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := session.State(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.CurrentState != "number_of_pizzas" || state.NextState != "address" {
+		t.Fatalf("state after the update: %s -> %s", state.CurrentState, state.NextState)
+	}
+	if memory, _ := state.Memory.(map[string]interface{}); memory["number_of_pizzas"] != "2" {
+		t.Errorf("memory after the update: %v", state.Memory)
+	}
+
+	reply, err := session.Send(ctx, "Sinza")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Message) != 1 || reply.Message[0] != "Your order has been placed." {
+		t.Errorf("reply after the update: %q", reply.Message)
+	}
+}
+
+func TestServerRestartsChatsInRemovedStates(t *testing.T) {
+	ctx := context.Background()
+	srv := sarufitest.NewServer()
+	defer srv.Close()
+	id := srv.AddBot(pizzaBot())
+
+	bot, err := srv.Application().GetBotContext(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := bot.Session("chat")
+	if _, err := session.Send(ctx, "I want pizza"); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := pizzaBot()
+	changed.Id = id
+	delete(changed.Flows, "number_of_pizzas")
+	changed.Flows["order_pizza"] = sarufi.Flow{State: &sarufi.FlowState{Message: []string{"Sold out."}, NextState: "end"}}
+	srv.AddBot(changed)
+
+	reply, err := session.Send(ctx, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Message) != 1 || reply.Message[0] != "Hi, How can I help you?" {
+		t.Errorf("reply after the state was removed: %q", reply.Message)
+	}
+}
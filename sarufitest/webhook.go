@@ -0,0 +1,271 @@
+package sarufitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	sarufi "github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/internal/strs"
+)
+
+// WebhookSimulatorOptions configures a WebhookSimulator.
+type WebhookSimulatorOptions struct {
+	// URL receives the webhooks instead of Bot.WebhookURL.
+	URL string
+	// HTTPClient posts the webhooks. It defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+	// MaxLatency is the longest a webhook may take to answer.
+	// Zero means no limit.
+	MaxLatency time.Duration
+	// EngineOptions configure the offline engine playing the bot.
+	EngineOptions []sarufi.EngineOption
+}
+
+// Step is a message of a scripted conversation and what is
+// expected from it.
+type Step struct {
+	// Message is sent to the bot.
+	Message string
+	// Reply, if not nil, is the reply the bot must send.
+	Reply []string
+	// State, if set, is the state the chat must be in afterwards.
+	State string
+	// Webhook, if set, is the trigger intent that must fire.
+	Webhook string
+}
+
+// Delivery is a webhook posted by a WebhookSimulator.
+type Delivery struct {
+	Event      sarufi.WebhookEvent
+	StatusCode int
+	Body       []byte
+	Duration   time.Duration
+	Err        error
+}
+
+// Turn is a played Step.
+type Turn struct {
+	Step       Step
+	Reply      sarufi.Conversation
+	Deliveries []Delivery
+}
+
+// SimulationError is returned when a played conversation does not
+// go as scripted. Turns holds the turns played so far, including
+// the failing one.
+type SimulationError struct {
+	Step    int
+	Message string
+	Turns   []Turn
+}
+
+func (e *SimulationError) Error() string {
+	return fmt.Sprintf("step %d: %s", e.Step+1, e.Message)
+}
+
+// WebhookSimulator plays scripted conversations with a bot through
+// the offline sarufi.Engine and, whenever the chat enters one of
+// Bot.WebhookTriggerIntents, posts the webhook Sarufi would send
+// to the bot's webhook URL. It checks that every webhook answers
+// with a 2xx status code within MaxLatency. The webhook of a
+// trigger intent fires with the message entering its state, so
+// with order_pizza as trigger intent:
+//
+//	sim, err := sarufitest.NewWebhookSimulator(bot, sarufitest.WebhookSimulatorOptions{
+//		URL: srv.URL + "/webhook",
+//	})
+//	_, err = sim.Play(ctx, "chat-1",
+//		sarufitest.Step{Message: "I want pizza", Webhook: "order_pizza"},
+//		sarufitest.Step{Message: "2", State: "number_of_pizzas"},
+//	)
+//
+// A WebhookSimulator is safe for concurrent use with distinct
+// chat IDs.
+type WebhookSimulator struct {
+	bot    sarufi.Bot
+	url    string
+	opts   WebhookSimulatorOptions
+	engine *sarufi.Engine
+
+	mu         sync.Mutex
+	pending    map[string][]sarufi.Transition
+	deliveries []Delivery
+}
+
+// NewWebhookSimulator returns a WebhookSimulator playing the
+// intents and flows the bot has now. It fails if the webhook URL
+// is not valid or a trigger intent is not in bot.Intents, as
+// Bot.ConfigureWebhook does.
+func NewWebhookSimulator(bot *sarufi.Bot, opts WebhookSimulatorOptions) (*WebhookSimulator, error) {
+	s := &WebhookSimulator{
+		bot:     *bot,
+		url:     opts.URL,
+		opts:    opts,
+		pending: make(map[string][]sarufi.Transition),
+	}
+	if s.url == "" {
+		s.url = bot.WebhookURL
+	}
+	// Check the settings the way the SDK does before sending them,
+	// on a copy as the bot may have no ID yet.
+	check := sarufi.Bot{Id: 1, Intents: bot.Intents}
+	if err := check.ConfigureWebhook(s.url, bot.WebhookTriggerIntents...); err != nil {
+		return nil, err
+	}
+	if s.opts.HTTPClient == nil {
+		s.opts.HTTPClient = http.DefaultClient
+	}
+	s.bot.WebhookTriggerIntents = append([]string(nil), bot.WebhookTriggerIntents...)
+
+	engineOpts := append([]sarufi.EngineOption{}, opts.EngineOptions...)
+	engineOpts = append(engineOpts, sarufi.WithTransitionHook(s.record))
+	s.engine = sarufi.NewEngine(bot, engineOpts...)
+	return s, nil
+}
+
+// Engine returns the engine playing the bot.
+func (s *WebhookSimulator) Engine() *sarufi.Engine {
+	return s.engine
+}
+
+// Play sends the messages of the steps in order in the given chat,
+// posting webhooks as they fire. It stops at the first step that
+// does not go as expected and returns a *SimulationError.
+func (s *WebhookSimulator) Play(ctx context.Context, chatID string, steps ...Step) ([]Turn, error) {
+	turns := make([]Turn, 0, len(steps))
+	for i, step := range steps {
+		reply, err := s.engine.RespondContext(ctx, chatID, step.Message)
+		if err != nil {
+			return turns, err
+		}
+		turn := Turn{Step: step, Reply: reply}
+		for _, transition := range s.take(chatID) {
+			if !s.triggers(transition.State) {
+				continue
+			}
+			delivery := s.deliver(ctx, transition)
+			turn.Deliveries = append(turn.Deliveries, delivery)
+			if err := ctx.Err(); err != nil {
+				return append(turns, turn), err
+			}
+		}
+		turns = append(turns, turn)
+
+		if problem := s.check(step, turn); problem != "" {
+			return turns, &SimulationError{Step: i, Message: problem, Turns: turns}
+		}
+	}
+	return turns, nil
+}
+
+// Deliveries returns every webhook posted so far.
+func (s *WebhookSimulator) Deliveries() []Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Delivery(nil), s.deliveries...)
+}
+
+// record is the transition hook of the engine.
+func (s *WebhookSimulator) record(transition sarufi.Transition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[transition.ChatID] = append(s.pending[transition.ChatID], transition)
+}
+
+func (s *WebhookSimulator) take(chatID string) []sarufi.Transition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	transitions := s.pending[chatID]
+	delete(s.pending, chatID)
+	return transitions
+}
+
+func (s *WebhookSimulator) triggers(state string) bool {
+	for _, intent := range s.bot.WebhookTriggerIntents {
+		if intent == state {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver posts the webhook of a transition and records the result.
+func (s *WebhookSimulator) deliver(ctx context.Context, transition sarufi.Transition) Delivery {
+	delivery := Delivery{Event: sarufi.WebhookEvent{
+		BotID:     s.bot.Id,
+		ChatID:    transition.ChatID,
+		Intent:    transition.State,
+		State:     transition.State,
+		NextState: transition.NextState,
+		Memory:    transition.Memory,
+		Message:   transition.Message,
+	}}
+
+	start := time.Now()
+	delivery.StatusCode, delivery.Body, delivery.Err = s.post(ctx, &delivery.Event)
+	delivery.Duration = time.Since(start)
+
+	s.mu.Lock()
+	s.deliveries = append(s.deliveries, delivery)
+	s.mu.Unlock()
+	return delivery
+}
+
+func (s *WebhookSimulator) post(ctx context.Context, event *sarufi.WebhookEvent) (int, []byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode, body, err
+}
+
+// check returns what went wrong in a turn, or "" if nothing did.
+func (s *WebhookSimulator) check(step Step, turn Turn) string {
+	var problems []string
+	for _, d := range turn.Deliveries {
+		switch {
+		case d.Err != nil:
+			problems = append(problems, fmt.Sprintf("webhook %q failed: %v", d.Event.Intent, d.Err))
+		case d.StatusCode < 200 || d.StatusCode > 299:
+			problems = append(problems, fmt.Sprintf("webhook %q answered with status code %d", d.Event.Intent, d.StatusCode))
+		case s.opts.MaxLatency > 0 && d.Duration > s.opts.MaxLatency:
+			problems = append(problems, fmt.Sprintf("webhook %q took %v, more than %v", d.Event.Intent, d.Duration, s.opts.MaxLatency))
+		}
+	}
+	if step.Reply != nil && !strs.Equal(step.Reply, turn.Reply.Message) {
+		problems = append(problems, fmt.Sprintf("reply is %q, want %q", turn.Reply.Message, step.Reply))
+	}
+	if step.State != "" && turn.Reply.CurrentState != step.State {
+		problems = append(problems, fmt.Sprintf("state is %q, want %q", turn.Reply.CurrentState, step.State))
+	}
+	if step.Webhook != "" {
+		fired := false
+		for _, d := range turn.Deliveries {
+			fired = fired || d.Event.Intent == step.Webhook
+		}
+		if !fired {
+			problems = append(problems, fmt.Sprintf("webhook %q did not fire", step.Webhook))
+		}
+	}
+	return strings.Join(problems, "; ")
+}
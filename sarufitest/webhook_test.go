@@ -0,0 +1,150 @@
+package sarufitest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	sarufi "github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/sarufitest"
+)
+
+// webhookServer answers webhooks with status and records their
+// events.
+func webhookServer(t *testing.T, status int) (*httptest.Server, func() []sarufi.WebhookEvent) {
+	t.Helper()
+	var mu sync.Mutex
+	var events []sarufi.WebhookEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event sarufi.WebhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("webhook body: %v", err)
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []sarufi.WebhookEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]sarufi.WebhookEvent(nil), events...)
+	}
+}
+
+// TestWebhookSimulatorReadme runs the example of the README.
+func TestWebhookSimulatorReadme(t *testing.T) {
+	ctx := context.Background()
+	webhookServer, events := webhookServer(t, http.StatusOK)
+	example_bot := pizzaBot()
+	example_bot.WebhookTriggerIntents = []string{"order_pizza"}
+
+	sim, err := sarufitest.NewWebhookSimulator(&example_bot, sarufitest.WebhookSimulatorOptions{
+		URL:        webhookServer.URL + "/webhook",
+		MaxLatency: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With order_pizza in WebhookTriggerIntents, its webhook fires
+	// with the message entering the order_pizza state.
+	_, err = sim.Play(ctx, "chat-1",
+		sarufitest.Step{
+			Message: "I want pizza",
+			Reply:   []string{"Sure, How many pizzas would you like to order?"},
+			Webhook: "order_pizza",
+		},
+		sarufitest.Step{Message: "2", State: "number_of_pizzas"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := events()
+	if len(got) != 1 {
+		t.Fatalf("%d webhooks posted, want 1", len(got))
+	}
+	if got[0].Intent != "order_pizza" || got[0].ChatID != "chat-1" || got[0].Message != "I want pizza" {
+		t.Errorf("webhook event %+v", got[0])
+	}
+}
+
+func TestWebhookSimulatorFailures(t *testing.T) {
+	ctx := context.Background()
+	bot := pizzaBot()
+	bot.WebhookTriggerIntents = []string{"order_pizza"}
+
+	tests := []struct {
+		name   string
+		status int
+		steps  []sarufitest.Step
+		step   int
+	}{
+		{
+			"webhook expected on the wrong step",
+			http.StatusOK,
+			[]sarufitest.Step{{Message: "I want pizza"}, {Message: "2", Webhook: "order_pizza"}},
+			1,
+		},
+		{
+			"webhook failing",
+			http.StatusInternalServerError,
+			[]sarufitest.Step{{Message: "I want pizza"}},
+			0,
+		},
+		{
+			"wrong reply",
+			http.StatusOK,
+			[]sarufitest.Step{{Message: "hello", Reply: []string{"Bye"}}},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := webhookServer(t, tt.status)
+			sim, err := sarufitest.NewWebhookSimulator(&bot, sarufitest.WebhookSimulatorOptions{URL: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = sim.Play(ctx, "chat", tt.steps...)
+			var simErr *sarufitest.SimulationError
+			if !errors.As(err, &simErr) {
+				t.Fatalf("err = %v, want a SimulationError", err)
+			}
+			if simErr.Step != tt.step {
+				t.Errorf("failed at step %d, want %d: %v", simErr.Step, tt.step, simErr)
+			}
+		})
+	}
+}
+
+func TestNewWebhookSimulatorChecks(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		intents []string
+		valid   bool
+	}{
+		{"valid", "http://localhost/webhook", []string{"order_pizza"}, true},
+		{"plain http", "http://example.com/webhook", []string{"order_pizza"}, false},
+		{"unknown intent", "https://example.com/webhook", []string{"refund"}, false},
+		{"flow without an intent", "https://example.com/webhook", []string{"number_of_pizzas"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := pizzaBot()
+			bot.WebhookTriggerIntents = tt.intents
+			_, err := sarufitest.NewWebhookSimulator(&bot, sarufitest.WebhookSimulatorOptions{URL: tt.url})
+			if (err == nil) != tt.valid {
+				t.Errorf("err = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package sarufi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// DefaultChannel is the channel messages are sent on unless
+// Session.SetChannel says otherwise.
+const DefaultChannel = "general"
+
+// Session is a conversation of one chat with a bot. Unlike
+// Bot.Respond, which stores the chat ID and replies on the shared
+// Bot, every reply is returned as a value, so many sessions can
+// run concurrently from the same Bot. A Session is safe for
+// concurrent use.
+type Session struct {
+	botID  int
+	client *Client
+
+	mu      sync.Mutex
+	chatID  string
+	channel string
+	last    *Reply
+}
+
+// Session returns a session for the given chat ID. An empty chat
+// ID starts a new chat with a random ID. The session keeps the
+// bot's ID and client; later changes to the bot do not affect it.
+func (bot *Bot) Session(chatID string) *Session {
+	if chatID == "" {
+		chatID = uuid.New().String()
+	}
+	return &Session{
+		botID:   bot.Id,
+		client:  bot.apiClient(),
+		chatID:  chatID,
+		channel: DefaultChannel,
+	}
+}
+
+// ChatID returns the chat ID of the session.
+func (s *Session) ChatID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.chatID
+}
+
+// SetChannel sets the channel messages are sent on.
+func (s *Session) SetChannel(channel string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channel = channel
+}
+
+// Send sends a text message and returns the bot's reply.
+func (s *Session) Send(ctx context.Context, message string) (*Reply, error) {
+	return s.SendMessage(ctx, TextMessage(message))
+}
+
+// SendMessage sends any kind of Message, such as a button reply
+// or a location, and returns the bot's reply.
+func (s *Session) SendMessage(ctx context.Context, message Message) (*Reply, error) {
+	if s.botID == 0 {
+		return nil, fmt.Errorf("No bot exists")
+	}
+	s.mu.Lock()
+	chatID, channel := s.chatID, s.channel
+	s.mu.Unlock()
+
+	reply, _, err := sendMessage(ctx, s.client, s.botID, chatID, message, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.chatID == chatID {
+		s.last = reply
+	}
+	s.mu.Unlock()
+	return reply, nil
+}
+
+// Last returns the latest reply of the session, or nil.
+func (s *Session) Last() *Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// State asks the API for the current and next state of the chat.
+func (s *Session) State(ctx context.Context) (Conversation, error) {
+	if s.botID == 0 {
+		return Conversation{}, fmt.Errorf("No bot exists")
+	}
+	return fetchChatState(ctx, s.client, s.botID, s.ChatID())
+}
+
+// History returns the conversation history of the chat.
+func (s *Session) History(ctx context.Context) ([]ConversationHistory, error) {
+	if s.botID == 0 {
+		return nil, fmt.Errorf("No bot exists")
+	}
+	return fetchChatHistory(ctx, s.client, s.botID, s.ChatID())
+}
+
+// Reset starts over with a new random chat ID, so the bot sees a
+// new conversation, and forgets the latest reply.
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chatID = uuid.New().String()
+	s.last = nil
+}
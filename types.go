@@ -3,24 +3,29 @@ package sarufi
 // Type Bot. All the fields are matched to the API JSON response.
 // You can read more here https://neurotech-africa.stoplight.io/docs/sarufi/a3135fbb09470-create-new-chatbot
 type Bot struct {
-	Id                        int                    `json:"id"`
-	Name                      string                 `json:"name"`
-	Industry                  string                 `json:"industry"`
-	Description               string                 `json:"description"`
-	UserID                    int                    `json:"user_id"`
-	VisibleOnCommunity        bool                   `json:"visible_on_community"`
-	Intents                   map[string][]string    `json:"intents"`
-	Flows                     map[string]interface{} `json:"flows"`
-	ModelName                 string                 `json:"model_name"`
-	WebhookURL                string                 `json:"webhook_url"`
-	WebhookTriggerIntents     []string               `json:"webhook_trigger_intents"`
-	EvaluationMetrics         interface{}            `json:"evaluation_metrics"`
-	ChatID                    string                 `json:"chat_id"`
+	Id                        int                 `json:"id"`
+	Name                      string              `json:"name"`
+	Industry                  string              `json:"industry"`
+	Description               string              `json:"description"`
+	UserID                    int                 `json:"user_id"`
+	VisibleOnCommunity        bool                `json:"visible_on_community"`
+	Intents                   map[string][]string `json:"intents"`
+	Flows                     Flows               `json:"flows"`
+	ModelName                 string              `json:"model_name"`
+	WebhookURL                string              `json:"webhook_url"`
+	WebhookTriggerIntents     []string            `json:"webhook_trigger_intents"`
+	EvaluationMetrics         interface{}         `json:"evaluation_metrics"`
+	ChatID                    string              `json:"chat_id"`
 	Conversation              Conversation
 	ConversationWithKnowledge ConversationWithKnowledge
 	Prediction                Prediction
 	ChatUsers                 []ChatUser
 	ConversationHistory       []ConversationHistory `json:"conversation_history"`
+
+	client *Client
+	// version is the fingerprint of the definition the bot had on
+	// the server when it was fetched, to detect stale updates.
+	version string
 }
 
 // This type will be used in the conversation history
@@ -57,9 +62,10 @@ type Prediction struct {
 // Conversation will hold all conversation
 // related data on none knowledge base bot
 type Conversation struct {
-	Message   []string `json:"message"`
-	Memory    Memory   `json:"memory"`
-	NextState string   `json:"next_state"`
+	Message      []string `json:"message"`
+	Memory       Memory   `json:"memory"`
+	CurrentState string   `json:"current_state"`
+	NextState    string   `json:"next_state"`
 }
 
 // ConversationWithKnowledge will hold all conversation
@@ -77,9 +83,12 @@ type Actions struct {
 type Memory interface{}
 
 // Application will hold all application methods.
-// It also has details about the user
+// It also has details about the user. Use NewApplication
+// to configure the client it talks to.
 type Application struct {
 	User User `json:"user"`
+
+	client *Client
 }
 
 type User struct {
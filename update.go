@@ -0,0 +1,178 @@
+package sarufi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrStaleBot is returned by UpdateBot and UpdateBotFields when
+// the bot changed on the server since the copy being updated was
+// fetched. Fetch it again with GetBot, apply the changes to the
+// fresh bot and update again.
+var ErrStaleBot = errors.New("bot changed on the server since it was fetched")
+
+// BotUpdate holds the fields to change with UpdateBotFields. Only
+// the fields that are not nil are sent; use StringField and
+// BoolField to set them. Setting a field to an empty value clears it.
+//
+// Base, if set, is the bot the changes were made from, as fetched
+// with GetBot or GetAllBots. The update is then refused with
+// ErrStaleBot if the bot changed on the server since.
+type BotUpdate struct {
+	Base *Bot `json:"-"`
+
+	Name                  *string              `json:"name,omitempty"`
+	Description           *string              `json:"description,omitempty"`
+	Industry              *string              `json:"industry,omitempty"`
+	VisibleOnCommunity    *bool                `json:"visible_on_community,omitempty"`
+	ModelName             *string              `json:"model_name,omitempty"`
+	WebhookURL            *string              `json:"webhook_url,omitempty"`
+	WebhookTriggerIntents *[]string            `json:"webhook_trigger_intents,omitempty"`
+	Intents               *map[string][]string `json:"intents,omitempty"`
+	Flows                 *Flows               `json:"flows,omitempty"`
+}
+
+// StringField returns a pointer to s, to set a string field of a
+// BotUpdate.
+func StringField(s string) *string {
+	return &s
+}
+
+// BoolField returns a pointer to b, to set a boolean field of a
+// BotUpdate.
+func BoolField(b bool) *bool {
+	return &b
+}
+
+// IsZero reports whether no field is set, Base aside.
+func (u BotUpdate) IsZero() bool {
+	u.Base = nil
+	return u == BotUpdate{}
+}
+
+// definitionOf returns an update setting every field of the bot
+// definition, leaving out runtime fields such as Conversation.
+func definitionOf(bot *Bot) BotUpdate {
+	intents := bot.Intents
+	if intents == nil {
+		intents = map[string][]string{}
+	}
+	flows := bot.Flows
+	if flows == nil {
+		flows = Flows{}
+	}
+	triggers := bot.WebhookTriggerIntents
+	if triggers == nil {
+		triggers = []string{}
+	}
+	return BotUpdate{
+		Name:                  &bot.Name,
+		Description:           &bot.Description,
+		Industry:              &bot.Industry,
+		VisibleOnCommunity:    &bot.VisibleOnCommunity,
+		ModelName:             &bot.ModelName,
+		WebhookURL:            &bot.WebhookURL,
+		WebhookTriggerIntents: &triggers,
+		Intents:               &intents,
+		Flows:                 &flows,
+	}
+}
+
+// UpdateBotFields changes only the fields set in update and
+// returns the updated bot:
+//
+//	bot, err := app.UpdateBotFields(ctx, id, sarufi.BotUpdate{
+//		Base:        fetched,
+//		Description: sarufi.StringField("Orders pizza and drinks"),
+//	})
+//
+// When update.Base is set, the bot is fetched again first and the
+// update is refused with ErrStaleBot if the server copy changed
+// since Base was fetched.
+func (app *Application) UpdateBotFields(ctx context.Context, id int, update BotUpdate) (*Bot, error) {
+	if update.IsZero() {
+		return nil, fmt.Errorf("No fields to update")
+	}
+	if update.Base != nil && update.Base.Id != id {
+		return nil, fmt.Errorf("update of bot %d is based on bot %d", id, update.Base.Id)
+	}
+	body, version, err := app.updateBot(ctx, id, update.Base, update)
+	if err != nil {
+		return nil, err
+	}
+	var bot Bot
+	if err := json.Unmarshal(body, &bot); err != nil {
+		return nil, err
+	}
+	bot.client = app.Client()
+	bot.version = version
+	return &bot, nil
+}
+
+// updateBot checks that base, if any, is not stale, sends the
+// update and returns the server answer with its version.
+func (app *Application) updateBot(ctx context.Context, id int, base *Bot, update BotUpdate) ([]byte, string, error) {
+	client := app.Client()
+	if !checkToken(client.key()) {
+		return nil, "", fmt.Errorf("Error: No token available")
+	}
+	if base != nil {
+		if err := client.checkFresh(ctx, id, base.version); err != nil {
+			return nil, "", err
+		}
+	}
+
+	jsonParams, err := json.Marshal(update)
+	if err != nil {
+		return nil, "", err
+	}
+	url := client.url(fmt.Sprintf("chatbot/%d", id))
+	body, err := client.makeRequest(ctx, "PUT", url, bytes.NewBuffer(jsonParams), forBot(id))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var bot Bot
+	if err := json.Unmarshal(body, &bot); err != nil {
+		return nil, "", err
+	}
+	return body, fingerprint(&bot), nil
+}
+
+// checkFresh fetches the bot and compares it with the version it
+// had when it was fetched before. Bots without a version, built by
+// hand, are not checked.
+func (c *Client) checkFresh(ctx context.Context, id int, want string) error {
+	if want == "" {
+		return nil
+	}
+
+	url := c.url(fmt.Sprintf("chatbot/%d", id))
+	body, err := c.makeRequest(ctx, "GET", url, nil, forBot(id))
+	if err != nil {
+		return err
+	}
+	var remote Bot
+	if err := json.Unmarshal(body, &remote); err != nil {
+		return err
+	}
+	if fingerprint(&remote) != want {
+		return fmt.Errorf("bot %d: %w", id, ErrStaleBot)
+	}
+	return nil
+}
+
+// fingerprint hashes the definition of a bot.
+func fingerprint(bot *Bot) string {
+	data, err := json.Marshal(definitionOf(bot))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
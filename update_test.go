@@ -0,0 +1,132 @@
+package sarufi_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sarufi "github.com/sarufi-io/sarufi-golang-sdk"
+	"github.com/sarufi-io/sarufi-golang-sdk/sarufitest"
+)
+
+func TestUpdateBotStale(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		fetch func(t *testing.T, app *sarufi.Application, id int) *sarufi.Bot
+		stale bool
+	}{
+		{
+			"GetBot",
+			func(t *testing.T, app *sarufi.Application, id int) *sarufi.Bot {
+				bot, err := app.GetBotContext(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return bot
+			},
+			true,
+		},
+		{
+			"GetAllBots",
+			func(t *testing.T, app *sarufi.Application, id int) *sarufi.Bot {
+				bots, err := app.GetAllBotsContext(ctx)
+				if err != nil || len(bots) != 1 {
+					t.Fatalf("bots %v, err %v", bots, err)
+				}
+				return &bots[0]
+			},
+			true,
+		},
+		{
+			"built by hand",
+			func(t *testing.T, app *sarufi.Application, id int) *sarufi.Bot {
+				bot := &sarufi.Bot{Id: id, Name: "Pizza"}
+				bot.SetClient(app.Client())
+				return bot
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := sarufitest.NewServer()
+			defer srv.Close()
+			id := srv.AddBot(sarufi.Bot{Name: "Pizza"})
+			app := srv.Application()
+
+			// a is fetched, the bot changes, b sees the change.
+			a := tt.fetch(t, app, id)
+			if _, err := srv.Application().UpdateBotFields(ctx, id, sarufi.BotUpdate{Description: sarufi.StringField("Changed")}); err != nil {
+				t.Fatal(err)
+			}
+			b := tt.fetch(t, app, id)
+
+			a.Industry = "Food"
+			err := app.UpdateBotContext(ctx, a)
+			if got := errors.Is(err, sarufi.ErrStaleBot); got != tt.stale {
+				t.Fatalf("err = %v, want stale %v", err, tt.stale)
+			}
+
+			if !tt.stale {
+				return
+			}
+			// b is fresh, and stays so after its own updates.
+			for _, industry := range []string{"Food", "Drinks"} {
+				b.Industry = industry
+				if err := app.UpdateBotContext(ctx, b); err != nil {
+					t.Fatalf("updating the fresh bot: %v", err)
+				}
+			}
+			remote, _ := srv.Bot(id)
+			if remote.Description != "Changed" || remote.Industry != "Drinks" {
+				t.Errorf("remote bot %+v", remote)
+			}
+		})
+	}
+}
+
+func TestUpdateBotFields(t *testing.T) {
+	ctx := context.Background()
+	srv := sarufitest.NewServer()
+	defer srv.Close()
+	id := srv.AddBot(sarufi.Bot{Name: "Pizza", Description: "Orders pizza", Industry: "Food"})
+	app := srv.Application()
+
+	if _, err := app.UpdateBotFields(ctx, id, sarufi.BotUpdate{}); err == nil {
+		t.Error("empty update accepted")
+	}
+
+	base, err := app.GetBotContext(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.UpdateBotFields(ctx, id, sarufi.BotUpdate{Base: base}); err == nil {
+		t.Error("update with only a base accepted")
+	}
+	if _, err := app.UpdateBotFields(ctx, id+1, sarufi.BotUpdate{Base: base, Name: sarufi.StringField("x")}); err == nil {
+		t.Error("update based on another bot accepted")
+	}
+
+	updated, err := app.UpdateBotFields(ctx, id, sarufi.BotUpdate{Base: base, Description: sarufi.StringField("Sells pizza")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Description != "Sells pizza" || updated.Industry != "Food" {
+		t.Errorf("updated bot %+v", updated)
+	}
+
+	// base is now stale, the updated bot is not.
+	_, err = app.UpdateBotFields(ctx, id, sarufi.BotUpdate{Base: base, Industry: sarufi.StringField("Drinks")})
+	if !errors.Is(err, sarufi.ErrStaleBot) {
+		t.Errorf("err = %v, want ErrStaleBot", err)
+	}
+	if _, err := app.UpdateBotFields(ctx, id, sarufi.BotUpdate{Base: updated, Industry: sarufi.StringField("Drinks")}); err != nil {
+		t.Errorf("update based on the updated bot: %v", err)
+	}
+	// Without a base nothing is checked.
+	if _, err := app.UpdateBotFields(ctx, id, sarufi.BotUpdate{Name: sarufi.StringField("Pizzeria")}); err != nil {
+		t.Error(err)
+	}
+}
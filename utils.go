@@ -1,6 +1,9 @@
 package sarufi
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,10 +11,6 @@ import (
 	"time"
 )
 
-const baseURL = "https://developers.sarufi.io/"
-
-var token = ""
-
 // A helper function to check if a file exists. It
 // accepts a filename string and returns a bool.
 func fileChecker(fileName string) bool {
@@ -33,33 +32,123 @@ func checkToken(token string) bool {
 	return token != ""
 }
 
-// A helper function to make requests easier
-func makeRequest(method, url string, data io.Reader) (int, []byte, error) {
-	req, err := http.NewRequest(method, url, data)
+// requestOptions carries per request settings of makeRequest.
+type requestOptions struct {
+	idempotent bool
+	botID      int
+}
+
+type requestOption func(*requestOptions)
+
+// forBot tells the rate limiter which bot a request is about.
+func forBot(id int) requestOption {
+	return func(o *requestOptions) {
+		o.botID = id
+	}
+}
+
+// idempotent marks a request, usually a POST, as safe to retry.
+func idempotent() requestOption {
+	return func(o *requestOptions) {
+		o.idempotent = true
+	}
+}
+
+// A helper function to make requests easier. The context is
+// honored while waiting for the response and reading its body.
+// Failed attempts are retried following the client's RetryPolicy.
+// Any non 2xx response is returned as an *APIError.
+func (c *Client) makeRequest(ctx context.Context, method, url string, data io.Reader, opts ...requestOption) ([]byte, error) {
+	var options requestOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var payload []byte
+	if data != nil {
+		var err error
+		if payload, err = io.ReadAll(data); err != nil {
+			return nil, err
+		}
+	}
+
+	policy := c.retryPolicy
+	canRetry := policy.retryable(method, options.idempotent)
+
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.wait(ctx, options.botID); err != nil {
+			return nil, err
+		}
+
+		body, wait, err := c.send(ctx, method, url, payload)
+		if err == nil {
+			return body, nil
+		}
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+			c.limiter.serverThrottled()
+		}
+		switch {
+		case !canRetry || attempt >= policy.MaxAttempts:
+			return nil, err
+		case errors.As(err, &apiErr):
+			if !policy.retryableStatus(apiErr.StatusCode) {
+				return nil, err
+			}
+		case !retryableError(ctx, err):
+			return nil, err
+		}
+
+		// The server asked for a longer wait than the caller accepts,
+		// give up rather than block.
+		if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+			return nil, err
+		}
+		if backoff := policy.backoff(attempt); backoff > wait {
+			wait = backoff
+		}
+		if sleep(ctx, wait) != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs a single attempt of a request. Along with a
+// failed response it returns the wait asked for by Retry-After.
+func (c *Client) send(ctx context.Context, method, url string, payload []byte) ([]byte, time.Duration, error) {
+	var data io.Reader
+	if payload != nil {
+		data = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, data)
 
 	if err != nil {
-		return 0, nil, err
+		return nil, 0, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	bearer := fmt.Sprintf("Bearer %s", token)
+	bearer := fmt.Sprintf("Bearer %s", c.key())
 	req.Header.Set("Authorization", bearer)
+	req.Header.Set("User-Agent", c.userAgent)
 
-	client := &http.Client{}
-
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 
 	if err != nil {
-		return 0, nil, err
+		return nil, 0, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, retryAfter(resp.Header), newAPIError(method, url, resp, body)
 	}
 
-	return resp.StatusCode, body, nil
+	return body, 0, nil
 }
 
 // ToDo;
@@ -0,0 +1,188 @@
+package sarufi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sarufi-io/sarufi-golang-sdk/internal/strs"
+)
+
+// Severity tells how serious a validation Issue is.
+type Severity int
+
+const (
+	// SeverityWarning marks definitions that work but are likely mistakes.
+	SeverityWarning Severity = iota
+	// SeverityError marks definitions that break conversations.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Codes of the issues reported by ValidateDefinition.
+const (
+	IssueMissingState          = "missing_state"
+	IssueIntentWithoutFlow     = "intent_without_flow"
+	IssueUnreachableFlow       = "unreachable_flow"
+	IssueChoiceWithoutFallback = "choice_without_fallback"
+	IssueEmptyExamples         = "empty_examples"
+	IssueDuplicateExample      = "duplicate_example"
+	IssueUnknownWebhookIntent  = "unknown_webhook_intent"
+)
+
+// Issue is a single problem found in a bot definition. Subject
+// is the name of the intent or state the issue is about.
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Subject  string   `json:"subject"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Subject, i.Message)
+}
+
+// Issues is the list of problems returned by validation.
+type Issues []Issue
+
+// HasErrors reports whether any issue has SeverityError.
+func (is Issues) HasErrors() bool {
+	for _, issue := range is {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the intents, flows and webhook settings of the
+// bot before they are sent with UpdateBot. See ValidateDefinition.
+func (bot *Bot) Validate() Issues {
+	return ValidateDefinition(bot.Intents, bot.Flows, bot.WebhookTriggerIntents)
+}
+
+// ValidateDefinition checks a bot definition without calling the
+// API. It reports next states that do not exist, intents without
+// a flow, flows no intent can reach, choices without a fallback
+// message, intents without examples, examples shared by several
+// intents and webhook trigger intents that do not exist.
+// Issues are sorted by subject, then code.
+func ValidateDefinition(intents map[string][]string, flows Flows, webhookTriggerIntents []string) Issues {
+	var issues Issues
+	add := func(severity Severity, code, subject, format string, args ...interface{}) {
+		issues = append(issues, Issue{
+			Severity: severity,
+			Code:     code,
+			Subject:  subject,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for name, flow := range flows {
+		for _, target := range flow.Targets() {
+			if _, ok := flows[target]; !ok && target != EndState {
+				add(SeverityError, IssueMissingState, name, "moves to state %q which does not exist", target)
+			}
+		}
+		if flow.Choice != nil && len(flow.Choice.FallbackMessage) == 0 {
+			add(SeverityWarning, IssueChoiceWithoutFallback, name, "choice has no fallback message")
+		}
+	}
+
+	owners := make(map[string][]string)
+	for name, examples := range intents {
+		if _, ok := flows[name]; !ok {
+			add(SeverityError, IssueIntentWithoutFlow, name, "intent has no flow")
+		}
+		empty := true
+		for _, example := range examples {
+			key := normalizeExample(example)
+			if key == "" {
+				continue
+			}
+			empty = false
+			owners[key] = strs.AppendUnique(owners[key], name)
+		}
+		if empty {
+			add(SeverityError, IssueEmptyExamples, name, "intent has no examples")
+		}
+	}
+	for example, names := range owners {
+		if len(names) < 2 {
+			continue
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add(SeverityWarning, IssueDuplicateExample, name, "example %q is also used by %s", example, quoteOthers(names, name))
+		}
+	}
+
+	reachable := reachableStates(intents, flows)
+	for name := range flows {
+		if !reachable[name] {
+			add(SeverityWarning, IssueUnreachableFlow, name, "flow cannot be reached from any intent")
+		}
+	}
+
+	for _, name := range webhookTriggerIntents {
+		if _, ok := intents[name]; !ok {
+			add(SeverityError, IssueUnknownWebhookIntent, name, "webhook trigger intent does not exist")
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Subject != issues[j].Subject {
+			return issues[i].Subject < issues[j].Subject
+		}
+		if issues[i].Code != issues[j].Code {
+			return issues[i].Code < issues[j].Code
+		}
+		return issues[i].Message < issues[j].Message
+	})
+	return issues
+}
+
+// reachableStates walks the flows starting from every intent.
+func reachableStates(intents map[string][]string, flows Flows) map[string]bool {
+	reachable := make(map[string]bool)
+	var queue []string
+	for name := range intents {
+		if _, ok := flows[name]; ok && !reachable[name] {
+			reachable[name] = true
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, target := range flows[name].Targets() {
+			if _, ok := flows[target]; ok && !reachable[target] {
+				reachable[target] = true
+				queue = append(queue, target)
+			}
+		}
+	}
+	return reachable
+}
+
+func normalizeExample(example string) string {
+	return strings.ToLower(strings.Join(strings.Fields(example), " "))
+}
+
+// quoteOthers lists the quoted names other than skip.
+func quoteOthers(names []string, skip string) string {
+	var others []string
+	for _, name := range names {
+		if name != skip {
+			others = append(others, fmt.Sprintf("%q", name))
+		}
+	}
+	return strings.Join(others, ", ")
+}
@@ -0,0 +1,130 @@
+package sarufi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateDefinition(t *testing.T) {
+	end := func(messages ...string) Flow {
+		return Flow{State: &FlowState{Message: messages, NextState: EndState}}
+	}
+
+	tests := []struct {
+		name      string
+		intents   map[string][]string
+		flows     Flows
+		webhook   []string
+		issues    []string
+		hasErrors bool
+	}{
+		{
+			"valid",
+			map[string][]string{"greets": {"hi"}},
+			Flows{"greets": end("Hello")},
+			[]string{"greets"},
+			nil,
+			false,
+		},
+		{
+			"missing state",
+			map[string][]string{"greets": {"hi"}},
+			Flows{"greets": {State: &FlowState{Message: []string{"Hello"}, NextState: "name"}}},
+			nil,
+			[]string{"error greets missing_state"},
+			true,
+		},
+		{
+			"intent without flow",
+			map[string][]string{"greets": {"hi"}, "goodbye": {"bye"}},
+			Flows{"greets": end("Hello")},
+			nil,
+			[]string{"error goodbye intent_without_flow"},
+			true,
+		},
+		{
+			"unreachable flow",
+			map[string][]string{"greets": {"hi"}},
+			Flows{"greets": end("Hello"), "orphan": end("Lost")},
+			nil,
+			[]string{"warning orphan unreachable_flow"},
+			false,
+		},
+		{
+			"choice without fallback",
+			map[string][]string{"size": {"pizza size"}},
+			Flows{"size": {Choice: &ChoiceState{Options: map[string]string{"1": EndState}}}},
+			nil,
+			[]string{"warning size choice_without_fallback"},
+			false,
+		},
+		{
+			"empty examples",
+			map[string][]string{"greets": {" ", ""}},
+			Flows{"greets": end("Hello")},
+			nil,
+			[]string{"error greets empty_examples"},
+			true,
+		},
+		{
+			"duplicate example",
+			map[string][]string{"greets": {"Hi  there"}, "welcome": {"hi there"}},
+			Flows{"greets": end("Hello"), "welcome": end("Welcome")},
+			nil,
+			[]string{"warning greets duplicate_example", "warning welcome duplicate_example"},
+			false,
+		},
+		{
+			"unknown webhook intent",
+			map[string][]string{"greets": {"hi"}},
+			Flows{"greets": end("Hello")},
+			[]string{"order_pizza"},
+			[]string{"error order_pizza unknown_webhook_intent"},
+			true,
+		},
+		{
+			"reachable through choices",
+			map[string][]string{"size": {"pizza size"}},
+			Flows{
+				"size":  {Choice: &ChoiceState{Options: map[string]string{"1": "small"}, FallbackMessage: []string{"?"}}},
+				"small": end("Small it is"),
+			},
+			nil,
+			nil,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := ValidateDefinition(tt.intents, tt.flows, tt.webhook)
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.Severity.String()+" "+issue.Subject+" "+issue.Code)
+				if issue.Message == "" {
+					t.Errorf("issue %+v has no message", issue)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.issues) {
+				t.Errorf("issues %q, want %q", got, tt.issues)
+			}
+			if issues.HasErrors() != tt.hasErrors {
+				t.Errorf("HasErrors() = %v, want %v", issues.HasErrors(), tt.hasErrors)
+			}
+		})
+	}
+}
+
+func TestBotValidate(t *testing.T) {
+	bot := &Bot{
+		Intents:               map[string][]string{"greets": {"hi"}},
+		Flows:                 Flows{"greets": {State: &FlowState{Message: []string{"Hello"}, NextState: EndState}}},
+		WebhookTriggerIntents: []string{"goodbye"},
+	}
+	issues := bot.Validate()
+	if len(issues) != 1 || issues[0].Code != IssueUnknownWebhookIntent {
+		t.Errorf("issues %v", issues)
+	}
+	if want := `error: goodbye: webhook trigger intent does not exist`; issues[0].String() != want {
+		t.Errorf("String() = %q, want %q", issues[0].String(), want)
+	}
+}
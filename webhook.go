@@ -0,0 +1,155 @@
+package sarufi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+// DefaultWebhookMaxBodySize is the largest webhook payload accepted
+// by a WebhookHandler unless WebhookOptions says otherwise.
+const DefaultWebhookMaxBodySize = 1 << 20
+
+// WebhookEvent is the payload Sarufi posts to Bot.WebhookURL when
+// one of Bot.WebhookTriggerIntents fires.
+type WebhookEvent struct {
+	BotID     int                    `json:"bot_id"`
+	ChatID    string                 `json:"chat_id"`
+	Intent    string                 `json:"intent"`
+	State     string                 `json:"state"`
+	NextState string                 `json:"next_state"`
+	Memory    map[string]interface{} `json:"memory"`
+	Message   string                 `json:"message"`
+}
+
+// WebhookHandlerFunc handles a webhook event. Returning an error
+// makes the handler answer with a 500 status code.
+type WebhookHandlerFunc func(ctx context.Context, event *WebhookEvent) error
+
+// WebhookOptions configures a WebhookHandler.
+type WebhookOptions struct {
+	// MaxBodySize is the largest accepted payload in bytes.
+	// It defaults to DefaultWebhookMaxBodySize.
+	MaxBodySize int64
+	// Fallback handles events of intents without a callback.
+	// If nil, such events are acknowledged and dropped.
+	Fallback WebhookHandlerFunc
+	// OnError is told about events whose callback failed.
+	OnError func(event *WebhookEvent, err error)
+}
+
+// WebhookHandler is an http.Handler receiving Sarufi webhooks and
+// dispatching each event to the callback of its intent:
+//
+//	handler := sarufi.NewWebhookHandler(sarufi.WebhookOptions{})
+//	handler.On("order_pizza", func(ctx context.Context, event *sarufi.WebhookEvent) error {
+//		return placeOrder(ctx, event.ChatID, event.Memory)
+//	})
+//	http.Handle("/webhook", handler)
+//
+// Only POST requests with a JSON body are accepted.
+type WebhookHandler struct {
+	opts WebhookOptions
+
+	mu        sync.RWMutex
+	callbacks map[string]WebhookHandlerFunc
+}
+
+// NewWebhookHandler returns a WebhookHandler without callbacks.
+func NewWebhookHandler(opts WebhookOptions) *WebhookHandler {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultWebhookMaxBodySize
+	}
+	return &WebhookHandler{
+		opts:      opts,
+		callbacks: make(map[string]WebhookHandlerFunc),
+	}
+}
+
+// On registers the callback of an intent, replacing any previous one.
+func (h *WebhookHandler) On(intent string, fn WebhookHandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.callbacks[intent] = fn
+}
+
+// ServeHTTP implements http.Handler.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeWebhookStatus(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		writeWebhookStatus(w, http.StatusUnsupportedMediaType, "content type must be application/json")
+		return
+	}
+
+	event, err := h.decode(r)
+	if err != nil {
+		if errors.Is(err, errPayloadTooLarge) {
+			writeWebhookStatus(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		writeWebhookStatus(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.mu.RLock()
+	fn, ok := h.callbacks[event.Intent]
+	h.mu.RUnlock()
+	if !ok {
+		fn = h.opts.Fallback
+	}
+	if fn == nil {
+		writeWebhookStatus(w, http.StatusOK, "ignored")
+		return
+	}
+
+	if err := fn(r.Context(), event); err != nil {
+		if h.opts.OnError != nil {
+			h.opts.OnError(event, err)
+		}
+		writeWebhookStatus(w, http.StatusInternalServerError, "handler failed")
+		return
+	}
+	writeWebhookStatus(w, http.StatusOK, "ok")
+}
+
+var errPayloadTooLarge = errors.New("payload too large")
+
+// decode reads and checks the payload of a webhook request.
+func (h *WebhookHandler) decode(r *http.Request) (*WebhookEvent, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, h.opts.MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > h.opts.MaxBodySize {
+		return nil, errPayloadTooLarge
+	}
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	if event.ChatID == "" {
+		return nil, fmt.Errorf("invalid payload: chat_id is missing")
+	}
+	if event.Intent == "" {
+		return nil, fmt.Errorf("invalid payload: intent is missing")
+	}
+	if event.Memory == nil {
+		event.Memory = map[string]interface{}{}
+	}
+	return &event, nil
+}
+
+func writeWebhookStatus(w http.ResponseWriter, statusCode int, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
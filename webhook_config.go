@@ -0,0 +1,178 @@
+package sarufi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sarufi-io/sarufi-golang-sdk/internal/strs"
+)
+
+// ConfigureWebhook sets the webhook URL of the bot and the intents
+// that trigger it, replacing the previous ones. The URL must be
+// HTTPS, or HTTP on a loopback host for local development, and
+// every intent must exist in bot.Intents. Nothing is changed if
+// any check fails. For changes to take effect, call UpdateBot.
+func (bot *Bot) ConfigureWebhook(webhookURL string, intents ...string) error {
+	if bot.Id == 0 {
+		return fmt.Errorf("No bot exists")
+	}
+	if err := ValidateWebhookURL(webhookURL); err != nil {
+		return err
+	}
+	if err := bot.checkIntents(intents); err != nil {
+		return err
+	}
+	bot.WebhookURL = webhookURL
+	bot.WebhookTriggerIntents = addUnique([]string{}, intents...)
+	return nil
+}
+
+// AddWebhookTriggerIntent adds intents to the ones triggering the
+// webhook. Every intent must exist in bot.Intents.
+func (bot *Bot) AddWebhookTriggerIntent(intents ...string) error {
+	if bot.Id == 0 {
+		return fmt.Errorf("No bot exists")
+	}
+	if err := bot.checkIntents(intents); err != nil {
+		return err
+	}
+	bot.WebhookTriggerIntents = addUnique(bot.WebhookTriggerIntents, intents...)
+	return nil
+}
+
+// RemoveWebhookTriggerIntent removes intents from the ones
+// triggering the webhook. Intents that are not there are ignored.
+func (bot *Bot) RemoveWebhookTriggerIntent(intents ...string) error {
+	if bot.Id == 0 {
+		return fmt.Errorf("No bot exists")
+	}
+	remove := make(map[string]bool, len(intents))
+	for _, intent := range intents {
+		remove[intent] = true
+	}
+	kept := make([]string, 0, len(bot.WebhookTriggerIntents))
+	for _, intent := range bot.WebhookTriggerIntents {
+		if !remove[intent] {
+			kept = append(kept, intent)
+		}
+	}
+	bot.WebhookTriggerIntents = kept
+	return nil
+}
+
+// ProbeWebhook posts a test event to bot.WebhookURL and checks
+// that it answers with a 2xx status code, so an unreachable
+// endpoint is found before calling UpdateBot. The event has the
+// bot's ID, a "sarufi-probe" chat ID and the first trigger intent.
+// A nil httpClient uses http.DefaultClient.
+func (bot *Bot) ProbeWebhook(ctx context.Context, httpClient *http.Client) error {
+	if bot.Id == 0 {
+		return fmt.Errorf("No bot exists")
+	}
+	event := WebhookEvent{
+		BotID:  bot.Id,
+		ChatID: "sarufi-probe",
+		Intent: "sarufi_probe",
+		Memory: map[string]interface{}{},
+	}
+	if len(bot.WebhookTriggerIntents) > 0 {
+		event.Intent = bot.WebhookTriggerIntents[0]
+		event.State = event.Intent
+	}
+	return ProbeWebhookURL(ctx, httpClient, bot.WebhookURL, &event)
+}
+
+// ProbeWebhookURL posts event to webhookURL the way Sarufi does
+// and returns an error unless it answers with a 2xx status code.
+func ProbeWebhookURL(ctx context.Context, httpClient *http.Client, webhookURL string, event *WebhookEvent) error {
+	if err := ValidateWebhookURL(webhookURL); err != nil {
+		return err
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook %s is not reachable: %w", webhookURL, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s answered with status code %d", webhookURL, resp.StatusCode)
+	}
+	return nil
+}
+
+// ValidateWebhookURL checks that a webhook URL is absolute, has a
+// host and uses HTTPS. Plain HTTP is only allowed on loopback hosts
+// such as localhost, for local development.
+func ValidateWebhookURL(webhookURL string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL %q: %w", webhookURL, err)
+	}
+	if u.Host == "" || u.Hostname() == "" {
+		return fmt.Errorf("invalid webhook URL %q: no host", webhookURL)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if isLoopback(u.Hostname()) {
+			return nil
+		}
+		return fmt.Errorf("invalid webhook URL %q: must use https", webhookURL)
+	default:
+		return fmt.Errorf("invalid webhook URL %q: scheme must be https", webhookURL)
+	}
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// checkIntents returns an error naming the intents that are
+// not in bot.Intents.
+func (bot *Bot) checkIntents(intents []string) error {
+	var unknown []string
+	for _, intent := range intents {
+		if _, ok := bot.Intents[intent]; !ok {
+			unknown = append(unknown, fmt.Sprintf("%q", intent))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown webhook trigger intents: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// addUnique appends the values missing from list.
+func addUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		list = strs.AppendUnique(list, value)
+	}
+	return list
+}
@@ -0,0 +1,141 @@
+package sarufi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://example.com/webhook", true},
+		{"https://example.com:8443/webhook?key=1", true},
+		{"http://localhost:8080/webhook", true},
+		{"http://LOCALHOST/webhook", true},
+		{"http://127.0.0.1:8080/webhook", true},
+		{"http://[::1]:8080/webhook", true},
+		{"http://example.com/webhook", false},
+		{"http://10.0.0.1/webhook", false},
+		{"ftp://example.com/webhook", false},
+		{"ws://localhost/webhook", false},
+		{"example.com/webhook", false},
+		{"https:///webhook", false},
+		{"", false},
+		{"https://exa mple.com", false},
+	}
+	for _, tt := range tests {
+		err := ValidateWebhookURL(tt.url)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateWebhookURL(%q) = %v, want valid %v", tt.url, err, tt.valid)
+		}
+	}
+}
+
+func webhookBot() *Bot {
+	return &Bot{
+		Id:      1,
+		Intents: map[string][]string{"order_pizza": {"pizza"}, "goodbye": {"bye"}, "greets": {"hi"}},
+	}
+}
+
+func TestConfigureWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		intents []string
+		want    []string
+		valid   bool
+	}{
+		{"https", "https://example.com/webhook", []string{"order_pizza"}, []string{"order_pizza"}, true},
+		{"loopback", "http://localhost:8080/webhook", []string{"order_pizza", "goodbye"}, []string{"order_pizza", "goodbye"}, true},
+		{"duplicates", "https://example.com/webhook", []string{"goodbye", "goodbye"}, []string{"goodbye"}, true},
+		{"no intents", "https://example.com/webhook", nil, []string{}, true},
+		{"plain http", "http://example.com/webhook", []string{"order_pizza"}, nil, false},
+		{"unknown intent", "https://example.com/webhook", []string{"order_pizza", "refund"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := webhookBot()
+			bot.WebhookURL = "https://old.example.com"
+			bot.WebhookTriggerIntents = []string{"greets"}
+
+			err := bot.ConfigureWebhook(tt.url, tt.intents...)
+			if (err == nil) != tt.valid {
+				t.Fatalf("err = %v, want valid %v", err, tt.valid)
+			}
+			if !tt.valid {
+				if bot.WebhookURL != "https://old.example.com" || !reflect.DeepEqual(bot.WebhookTriggerIntents, []string{"greets"}) {
+					t.Errorf("failed configuration changed the bot: %q %q", bot.WebhookURL, bot.WebhookTriggerIntents)
+				}
+				return
+			}
+			if bot.WebhookURL != tt.url || !reflect.DeepEqual(bot.WebhookTriggerIntents, tt.want) {
+				t.Errorf("webhook %q %q, want %q %q", bot.WebhookURL, bot.WebhookTriggerIntents, tt.url, tt.want)
+			}
+		})
+	}
+
+	if err := (&Bot{}).ConfigureWebhook("https://example.com"); err == nil {
+		t.Error("webhook configured on a bot without an ID")
+	}
+}
+
+func TestWebhookTriggerIntents(t *testing.T) {
+	bot := webhookBot()
+	bot.WebhookTriggerIntents = []string{"order_pizza"}
+
+	if err := bot.AddWebhookTriggerIntent("goodbye", "order_pizza", "goodbye"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"order_pizza", "goodbye"}; !reflect.DeepEqual(bot.WebhookTriggerIntents, want) {
+		t.Errorf("after adding: %q, want %q", bot.WebhookTriggerIntents, want)
+	}
+	if err := bot.AddWebhookTriggerIntent("greets", "refund"); err == nil {
+		t.Error("unknown intent added")
+	}
+	if want := []string{"order_pizza", "goodbye"}; !reflect.DeepEqual(bot.WebhookTriggerIntents, want) {
+		t.Errorf("failed add changed the intents: %q", bot.WebhookTriggerIntents)
+	}
+
+	if err := bot.RemoveWebhookTriggerIntent("order_pizza", "refund", "order_pizza"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"goodbye"}; !reflect.DeepEqual(bot.WebhookTriggerIntents, want) {
+		t.Errorf("after removing: %q, want %q", bot.WebhookTriggerIntents, want)
+	}
+
+	var empty Bot
+	if empty.AddWebhookTriggerIntent("greets") == nil || empty.RemoveWebhookTriggerIntent("greets") == nil {
+		t.Error("trigger intents changed on a bot without an ID")
+	}
+}
+
+func TestProbeWebhook(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusNotFound} {
+		var event WebhookEvent
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			NewWebhookHandler(WebhookOptions{Fallback: func(ctx context.Context, e *WebhookEvent) error {
+				event = *e
+				return nil
+			}}).ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(status)
+		}))
+		bot := webhookBot()
+		if err := bot.ConfigureWebhook(server.URL, "order_pizza"); err != nil {
+			t.Fatal(err)
+		}
+		err := bot.ProbeWebhook(context.Background(), nil)
+		server.Close()
+		if (err == nil) != (status == http.StatusOK) {
+			t.Errorf("status %d: err = %v", status, err)
+		}
+		if event.ChatID != "sarufi-probe" || event.Intent != "order_pizza" || event.BotID != 1 {
+			t.Errorf("probe event %+v", event)
+		}
+	}
+}
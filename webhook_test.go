@@ -0,0 +1,116 @@
+package sarufi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	const pizza = `{"bot_id": 1, "chat_id": "chat", "intent": "order_pizza", "memory": {"number_of_pizzas": "2"}}`
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		fallback    bool
+		status      int
+		reply       string
+		handled     string
+	}{
+		{"routed", "POST", "application/json", pizza, false, http.StatusOK, "ok", "order_pizza"},
+		{"content type with charset", "POST", "application/json; charset=utf-8", pizza, false, http.StatusOK, "ok", "order_pizza"},
+		{"callback failed", "POST", "application/json", `{"chat_id": "chat", "intent": "fail"}`, false, http.StatusInternalServerError, "handler failed", "fail"},
+		{"unknown intent", "POST", "application/json", `{"chat_id": "chat", "intent": "goodbye"}`, false, http.StatusOK, "ignored", ""},
+		{"unknown intent with fallback", "POST", "application/json", `{"chat_id": "chat", "intent": "goodbye"}`, true, http.StatusOK, "ok", "fallback goodbye"},
+		{"wrong method", "GET", "application/json", "", false, http.StatusMethodNotAllowed, "method not allowed", ""},
+		{"wrong content type", "POST", "text/plain", pizza, false, http.StatusUnsupportedMediaType, "content type must be application/json", ""},
+		{"no content type", "POST", "", pizza, false, http.StatusUnsupportedMediaType, "content type must be application/json", ""},
+		{"too large", "POST", "application/json", `{"chat_id": "chat", "intent": "order_pizza", "message": "` + strings.Repeat("a", 100) + `"}`, false, http.StatusRequestEntityTooLarge, "payload too large", ""},
+		{"invalid JSON", "POST", "application/json", `{"chat_id":`, false, http.StatusBadRequest, "", ""},
+		{"missing chat ID", "POST", "application/json", `{"intent": "order_pizza"}`, false, http.StatusBadRequest, "invalid payload: chat_id is missing", ""},
+		{"missing intent", "POST", "application/json", `{"chat_id": "chat"}`, false, http.StatusBadRequest, "invalid payload: intent is missing", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled string
+			var failed error
+			opts := WebhookOptions{
+				MaxBodySize: 100,
+				OnError:     func(event *WebhookEvent, err error) { failed = err },
+			}
+			if tt.fallback {
+				opts.Fallback = func(ctx context.Context, event *WebhookEvent) error {
+					handled = "fallback " + event.Intent
+					return nil
+				}
+			}
+			h := NewWebhookHandler(opts)
+			h.On("order_pizza", func(ctx context.Context, event *WebhookEvent) error {
+				handled = event.Intent
+				if event.ChatID != "chat" || event.BotID != 1 || event.Memory["number_of_pizzas"] != "2" {
+					t.Errorf("event %+v", event)
+				}
+				return nil
+			})
+			h.On("fail", func(ctx context.Context, event *WebhookEvent) error {
+				handled = event.Intent
+				if event.Memory == nil {
+					t.Error("event without memory")
+				}
+				return errors.New("no oven")
+			})
+
+			req := httptest.NewRequest(tt.method, "/webhook", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status %d, want %d", rec.Code, tt.status)
+			}
+			var reply map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+				t.Fatalf("reply %q: %v", rec.Body.String(), err)
+			}
+			if tt.reply != "" && reply["status"] != tt.reply {
+				t.Errorf("status %q, want %q", reply["status"], tt.reply)
+			}
+			if handled != tt.handled {
+				t.Errorf("handled %q, want %q", handled, tt.handled)
+			}
+			if (failed != nil) != (tt.status == http.StatusInternalServerError) {
+				t.Errorf("OnError got %v", failed)
+			}
+			if tt.method != "POST" && rec.Header().Get("Allow") != "POST" {
+				t.Errorf("Allow: %q", rec.Header().Get("Allow"))
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerOnReplaces(t *testing.T) {
+	h := NewWebhookHandler(WebhookOptions{})
+	var calls []string
+	h.On("greets", func(ctx context.Context, event *WebhookEvent) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	h.On("greets", func(ctx context.Context, event *WebhookEvent) error {
+		calls = append(calls, "second")
+		return nil
+	})
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"chat_id": "c", "intent": "greets"}`))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if len(calls) != 1 || calls[0] != "second" {
+		t.Errorf("calls %q", calls)
+	}
+}